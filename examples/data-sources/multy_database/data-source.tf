@@ -0,0 +1,7 @@
+data "multy_database" "db" {
+  id = var.database_id
+}
+
+output "hostname" {
+  value = data.multy_database.db.hostname
+}
\ No newline at end of file
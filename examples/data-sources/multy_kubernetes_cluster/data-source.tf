@@ -0,0 +1,7 @@
+data "multy_kubernetes_cluster" "cluster" {
+  id = var.cluster_id
+}
+
+output "endpoint" {
+  value = data.multy_kubernetes_cluster.cluster.endpoint
+}
\ No newline at end of file
@@ -0,0 +1,3 @@
+data "multy_network_security_group" "nsg" {
+  id = var.network_security_group_id
+}
\ No newline at end of file
@@ -0,0 +1,3 @@
+data "multy_object_storage" "obj_storage" {
+  id = var.object_storage_id
+}
\ No newline at end of file
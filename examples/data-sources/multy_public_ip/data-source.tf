@@ -0,0 +1,7 @@
+data "multy_public_ip" "ip" {
+  id = var.public_ip_id
+}
+
+output "aws_public_ip_id" {
+  value = data.multy_public_ip.ip.aws.public_ip_id
+}
\ No newline at end of file
@@ -0,0 +1,16 @@
+data "multy_subnet" "subnet" {
+  id = var.subnet_id
+}
+
+# subnets can also be looked up by name, as long as only one matches
+data "multy_subnet" "app" {
+  name               = "app"
+  virtual_network_id = data.multy_virtual_network.vn.id
+}
+
+resource "multy_network_interface" "nic" {
+  name      = "dev-nic"
+  subnet_id = data.multy_subnet.app.id
+  cloud     = data.multy_virtual_network.vn.cloud
+  location  = data.multy_virtual_network.vn.location
+}
\ No newline at end of file
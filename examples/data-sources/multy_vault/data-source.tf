@@ -0,0 +1,9 @@
+data "multy_vault" "v" {
+  id = var.vault_id
+}
+
+resource "multy_vault_secret" "s" {
+  name     = "api-key"
+  vault_id = data.multy_vault.v.id
+  value    = "secret-1"
+}
\ No newline at end of file
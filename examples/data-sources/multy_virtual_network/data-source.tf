@@ -0,0 +1,13 @@
+data "multy_virtual_network" "vn" {
+  id = var.virtual_network_id
+}
+
+data "multy_virtual_network" "dev" {
+  name     = "dev-vn"
+  cloud    = "aws"
+  location = "us_east_1"
+}
+
+output "aws_vpc_id" {
+  value = data.multy_virtual_network.dev.aws.vpc_id
+}
\ No newline at end of file
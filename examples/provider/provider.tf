@@ -7,5 +7,7 @@ terraform {
 }
 
 provider "multy" {
-  api_key = "xxx"
+  api_key          = "xxx"
+  default_cloud    = "aws"
+  default_location = "eu_west_1"
 }
\ No newline at end of file
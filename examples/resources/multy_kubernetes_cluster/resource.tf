@@ -13,4 +13,8 @@ resource "multy_kubernetes_cluster" "cluster1" {
     disk_size_gb        = 10
     subnet_id           = multy_subnet.subnet1.id
   }
-}
+
+  timeouts {
+    create = "90m"
+  }
+}
\ No newline at end of file
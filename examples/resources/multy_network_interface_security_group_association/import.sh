@@ -0,0 +1,5 @@
+# Resources can be imported using the Multy resource id
+terraform import multy_network_interface_security_group_association.nic <resource_id>
+
+# Resources deployed with a named credential set from the provider are imported as <account>/<resource_id>
+terraform import multy_network_interface_security_group_association.nic <account>/<resource_id>
@@ -0,0 +1,5 @@
+# Resources can be imported using the Multy resource id
+terraform import multy_vault_access_policy.kv_ap <resource_id>
+
+# Resources deployed with a named credential set from the provider are imported as <account>/<resource_id>
+terraform import multy_vault_access_policy.kv_ap <account>/<resource_id>
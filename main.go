@@ -5,10 +5,22 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"log"
 	"terraform-provider-multy/multy"
+	"terraform-provider-multy/multy/common"
 )
 
 func main() {
-	err := providerserver.Serve(context.Background(), multy.New, providerserver.ServeOpts{
+	ctx := context.Background()
+	shutdownTracing, err := common.InitTracing(ctx)
+	if err != nil {
+		log.Printf("unable to set up tracing, %s", err)
+	}
+	defer func() {
+		if err := shutdownTracing(ctx); err != nil {
+			log.Printf("unable to export traces, %s", err)
+		}
+	}()
+
+	err = providerserver.Serve(ctx, multy.New, providerserver.ServeOpts{
 		Address: "hashicorp.com/dev/multy",
 	})
 	if err != nil {
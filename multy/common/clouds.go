@@ -0,0 +1,31 @@
+package common
+
+import (
+	"github.com/multycloud/multy/api/proto/commonpb"
+	"sync"
+)
+
+// CloudRegistry keeps the cloud of every resource the provider has read or applied, so that resources without a cloud
+// attribute, such as subnets, can be deployed with only the credentials of their parent's cloud.
+type CloudRegistry struct {
+	mu     sync.Mutex
+	clouds map[string]commonpb.CloudProvider
+}
+
+func NewCloudRegistry() *CloudRegistry {
+	return &CloudRegistry{clouds: map[string]commonpb.CloudProvider{}}
+}
+
+func (r *CloudRegistry) Add(id string, cloud commonpb.CloudProvider) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clouds[id] = cloud
+}
+
+// Get returns the cloud of the resource with the given id, if it's known.
+func (r *CloudRegistry) Get(id string) (commonpb.CloudProvider, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	cloud, ok := r.clouds[id]
+	return cloud, ok
+}
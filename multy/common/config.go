@@ -2,44 +2,121 @@ package common
 
 import (
 	"context"
+	"fmt"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	mproto "github.com/multycloud/multy/api/proto"
+	"github.com/multycloud/multy/api/proto/commonpb"
 	"github.com/multycloud/multy/api/proto/credspb"
 	"google.golang.org/grpc/metadata"
 	"google.golang.org/protobuf/proto"
+	"reflect"
+	"strings"
+	"time"
 )
 
 type ProviderConfig struct {
 	Client       mproto.MultyResourceServiceClient
 	ApiKey       string
-	Aws          *AwsConfig
-	Azure        *AzureConfig
-	Gcp          *GcpConfig
 	RefreshCache *RefreshCache
+	Networks     *NetworkRegistry
+	Clouds       *CloudRegistry
+	Types        *TypeRegistry
+
+	// Credentials are used by resources that don't set an account
+	Credentials
+	// Accounts are the named credential sets that resources can select through their account attribute
+	Accounts map[string]*Credentials
+
+	// DefaultCloud and DefaultLocation are used by resources that don't set cloud or location
+	DefaultCloud    string
+	DefaultLocation string
+
+	// Debug includes internal error details from the multy server in diagnostics
+	Debug bool
+}
+
+// Credentials holds the credentials of each cloud in a single account. Clouds without credentials are nil.
+type Credentials struct {
+	Aws   *AwsConfig
+	Azure *AzureConfig
+	Gcp   *GcpConfig
 }
 
+func (c *Credentials) HasCredentials(cloud commonpb.CloudProvider) bool {
+	switch cloud {
+	case commonpb.CloudProvider_AWS:
+		return c.Aws != nil
+	case commonpb.CloudProvider_AZURE:
+		return c.Azure != nil
+	case commonpb.CloudProvider_GCP:
+		return c.Gcp != nil
+	}
+	return false
+}
+
+// Equal returns true if both have the same credentials for the given cloud, or for every cloud if it's unknown.
+func (c *Credentials) Equal(other *Credentials, cloud commonpb.CloudProvider) bool {
+	all := cloud == commonpb.CloudProvider_UNKNOWN_PROVIDER
+	return ((!all && cloud != commonpb.CloudProvider_AWS) || c.Aws.Equal(other.Aws)) &&
+		((!all && cloud != commonpb.CloudProvider_AZURE) || reflect.DeepEqual(c.Azure, other.Azure)) &&
+		((!all && cloud != commonpb.CloudProvider_GCP) || reflect.DeepEqual(c.Gcp, other.Gcp))
+}
+
+// GetAccount returns the credential set with the given name, or the top-level credentials if account is empty.
+func (c *ProviderConfig) GetAccount(account string) (*Credentials, bool) {
+	if account == "" {
+		return &c.Credentials, true
+	}
+	creds, ok := c.Accounts[account]
+	return creds, ok
+}
+
+// AddHeaders adds the api key and the credentials of every configured cloud to the outgoing metadata. It should only be
+// used when the cloud of the resource isn't known.
 func (c *ProviderConfig) AddHeaders(ctx context.Context) (context.Context, error) {
-	var cloudCreds credspb.CloudCredentials
-	if c.Aws != nil {
-		cloudCreds.AwsCreds = &credspb.AwsCredentials{
-			AccessKey: c.Aws.AccessKeyId,
-			SecretKey: c.Aws.AccessKeySecret,
+	return c.AddHeadersForCloud(ctx, commonpb.CloudProvider_UNKNOWN_PROVIDER, "")
+}
 
-			SessionToken: c.Aws.SessionToken,
+// AddHeadersForCloud adds the api key and the credentials of the given cloud in the given account to the outgoing
+// metadata, replacing any added before. Credentials of other clouds and accounts aren't sent. If account is empty, the
+// top-level credentials are used.
+func (c *ProviderConfig) AddHeadersForCloud(ctx context.Context, cloud commonpb.CloudProvider, account string) (context.Context, error) {
+	creds, ok := c.GetAccount(account)
+	if !ok {
+		return nil, fmt.Errorf("account %s isn't configured in the provider", account)
+	}
+	all := cloud == commonpb.CloudProvider_UNKNOWN_PROVIDER
+	if !all && !creds.HasCredentials(cloud) {
+		name := strings.ToLower(cloud.String())
+		if account != "" {
+			return nil, fmt.Errorf("resource is deployed in %s, but account %s doesn't have %s credentials. Set them in "+
+				"the provider's %s_accounts attribute", name, account, name, name)
 		}
+		return nil, fmt.Errorf("resource is deployed in %s, but the provider doesn't have %s credentials. Set them in "+
+			"the provider's %s block or through environment variables", name, name, name)
 	}
-	if c.Azure != nil {
+
+	var cloudCreds credspb.CloudCredentials
+	if creds.Aws != nil && (all || cloud == commonpb.CloudProvider_AWS) {
+		awsCreds, err := creds.Aws.GetCredentials(ctx)
+		if err != nil {
+			return nil, err
+		}
+		cloudCreds.AwsCreds = awsCreds
+	}
+	if creds.Azure != nil && (all || cloud == commonpb.CloudProvider_AZURE) {
 		cloudCreds.AzureCreds = &credspb.AzureCredentials{
-			SubscriptionId: c.Azure.SubscriptionId,
-			TenantId:       c.Azure.TenantId,
-			ClientId:       c.Azure.ClientId,
-			ClientSecret:   c.Azure.ClientSecret,
+			SubscriptionId: creds.Azure.SubscriptionId,
+			TenantId:       creds.Azure.TenantId,
+			ClientId:       creds.Azure.ClientId,
+			ClientSecret:   creds.Azure.ClientSecret,
 		}
 	}
 
-	if c.Gcp != nil {
+	if creds.Gcp != nil && (all || cloud == commonpb.CloudProvider_GCP) {
 		cloudCreds.GcpCreds = &credspb.GCPCredentials{
-			Credentials: c.Gcp.Credentials,
-			Project:     c.Gcp.Project,
+			Credentials: creds.Gcp.Credentials,
+			Project:     creds.Gcp.Project,
 		}
 	}
 
@@ -47,9 +124,12 @@ func (c *ProviderConfig) AddHeaders(ctx context.Context) (context.Context, error
 	if err != nil {
 		return nil, err
 	}
-	ctx = metadata.AppendToOutgoingContext(ctx, "cloud-creds-bin", string(b))
+	md, _ := metadata.FromOutgoingContext(ctx)
+	md = md.Copy()
+	md.Set("cloud-creds-bin", string(b))
 	// TODO: retrieve user id from api key
-	return metadata.AppendToOutgoingContext(ctx, "api_key", c.ApiKey), nil
+	md.Set("api_key", c.ApiKey)
+	return metadata.NewOutgoingContext(ctx, md), nil
 }
 
 //
@@ -65,6 +145,61 @@ type AwsConfig struct {
 	AccessKeySecret string
 
 	SessionToken string
+
+	// Provider refreshes temporary credentials before they expire. If it's nil, the static credentials above are used.
+	Provider aws.CredentialsProvider
+	// Source is the configuration the credentials were resolved from.
+	Source AwsSource
+}
+
+// AwsSource holds the provider configuration that AWS credentials are resolved from.
+type AwsSource struct {
+	AccessKeyId            string
+	AccessKeySecret        string
+	SessionToken           string
+	Profile                string
+	SharedCredentialsFiles []string
+	RoleArn                string
+	SessionName            string
+	ExternalId             string
+	Duration               string
+}
+
+// Equal returns true if both are nil or resolve the same credentials. Temporary credentials are different every time
+// they're retrieved, so they're equal if they come from the same configuration.
+func (c *AwsConfig) Equal(other *AwsConfig) bool {
+	if c == nil || other == nil {
+		return c == other
+	}
+	if (c.Provider == nil) != (other.Provider == nil) || !reflect.DeepEqual(c.Source, other.Source) {
+		return false
+	}
+	return c.Provider != nil || (c.AccessKeyId == other.AccessKeyId && c.AccessKeySecret == other.AccessKeySecret &&
+		c.SessionToken == other.SessionToken)
+}
+
+// CredentialsExpiryWindow is how long before they expire temporary credentials are refreshed, so that they're still
+// valid when the server uses them.
+const CredentialsExpiryWindow = 10 * time.Minute
+
+// GetCredentials returns the static credentials, or fresh ones from c.Provider if it's set.
+func (c *AwsConfig) GetCredentials(ctx context.Context) (*credspb.AwsCredentials, error) {
+	if c.Provider == nil {
+		return &credspb.AwsCredentials{
+			AccessKey:    c.AccessKeyId,
+			SecretKey:    c.AccessKeySecret,
+			SessionToken: c.SessionToken,
+		}, nil
+	}
+	creds, err := c.Provider.Retrieve(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("unable to refresh aws credentials: %w", err)
+	}
+	return &credspb.AwsCredentials{
+		AccessKey:    creds.AccessKeyID,
+		SecretKey:    creds.SecretAccessKey,
+		SessionToken: creds.SessionToken,
+	}, nil
 }
 
 type AzureConfig struct {
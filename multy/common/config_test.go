@@ -0,0 +1,55 @@
+package common
+
+import (
+	"context"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/multycloud/multy/api/proto/commonpb"
+	"testing"
+)
+
+func TestCredentialsEqual(t *testing.T) {
+	assumeRole := func(key string) *AwsConfig {
+		return &AwsConfig{
+			AccessKeyId:     key,
+			AccessKeySecret: key,
+			Provider: aws.CredentialsProviderFunc(func(context.Context) (aws.Credentials, error) {
+				return aws.Credentials{AccessKeyID: key}, nil
+			}),
+			Source: AwsSource{Profile: "default", RoleArn: "arn:aws:iam::123456789012:role/multy"},
+		}
+	}
+	static := func(secret string) *AwsConfig {
+		return &AwsConfig{
+			AccessKeyId:     "key",
+			AccessKeySecret: secret,
+			Source:          AwsSource{AccessKeyId: "key", AccessKeySecret: secret},
+		}
+	}
+	otherRole := assumeRole("temporary")
+	otherRole.Source.RoleArn = "arn:aws:iam::123456789012:role/other"
+
+	tests := []struct {
+		name  string
+		a, b  *AwsConfig
+		equal bool
+	}{
+		{name: "same assumed role", a: assumeRole("temporary"), b: assumeRole("other-temporary"), equal: true},
+		{name: "different assumed roles", a: assumeRole("temporary"), b: otherRole, equal: false},
+		{name: "same static credentials", a: static("secret"), b: static("secret"), equal: true},
+		{name: "different static credentials", a: static("secret"), b: static("other"), equal: false},
+		{name: "static and assumed role", a: static("secret"), b: assumeRole("temporary"), equal: false},
+		{name: "no credentials", equal: true},
+		{name: "only one with credentials", a: static("secret"), equal: false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			a, b := &Credentials{Aws: tc.a}, &Credentials{Aws: tc.b}
+			if got := a.Equal(b, commonpb.CloudProvider_AWS); got != tc.equal {
+				t.Errorf("expected %t, got %t", tc.equal, got)
+			}
+			if got := b.Equal(a, commonpb.CloudProvider_UNKNOWN_PROVIDER); got != tc.equal {
+				t.Errorf("expected %t for every cloud, got %t", tc.equal, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"github.com/Azure/go-autorest/autorest/adal"
+	"github.com/Azure/go-autorest/autorest/azure"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awscreds "github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
+	"golang.org/x/oauth2/google"
+)
+
+// Validate checks that the credentials are valid by getting the identity they belong to.
+func (c *AwsConfig) Validate(ctx context.Context) (string, error) {
+	creds, err := c.GetCredentials(ctx)
+	if err != nil {
+		return "", err
+	}
+	client := sts.NewFromConfig(aws.Config{
+		// STS is a global service, but the client still needs a region
+		Region:      "us-east-1",
+		Credentials: awscreds.NewStaticCredentialsProvider(creds.AccessKey, creds.SecretKey, creds.SessionToken),
+	})
+	out, err := client.GetCallerIdentity(ctx, &sts.GetCallerIdentityInput{})
+	if err != nil {
+		return "", err
+	}
+	return aws.ToString(out.Arn), nil
+}
+
+// Validate checks that the service principal can get a token for the Azure Resource Manager.
+func (c *AzureConfig) Validate(ctx context.Context) (string, error) {
+	oauthConfig, err := adal.NewOAuthConfig(azure.PublicCloud.ActiveDirectoryEndpoint, c.TenantId)
+	if err != nil {
+		return "", err
+	}
+	token, err := adal.NewServicePrincipalToken(*oauthConfig, c.ClientId, c.ClientSecret, azure.PublicCloud.ResourceManagerEndpoint)
+	if err != nil {
+		return "", err
+	}
+	if err := token.EnsureFreshWithContext(ctx); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("client %s in tenant %s", c.ClientId, c.TenantId), nil
+}
+
+// Validate checks that the credentials can get a token for Google Cloud.
+func (c *GcpConfig) Validate(ctx context.Context) (string, error) {
+	creds, err := google.CredentialsFromJSON(ctx, []byte(c.Credentials), "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return "", err
+	}
+	if _, err := creds.TokenSource.Token(); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("project %s", c.Project), nil
+}
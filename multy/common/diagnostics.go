@@ -0,0 +1,125 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/multycloud/multy/api/proto/errorspb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var fieldSegmentRegex = regexp.MustCompile(`^([A-Za-z0-9_]+)(?:\[(\d+)])?$`)
+
+// AddGrpcErrors adds err to diags. Validation errors reported by the server are added as separate diagnostics,
+// attached to the attribute they refer to if it can be found in the schema. fieldNames is passed to FieldPath.
+func AddGrpcErrors(ctx context.Context, diags *diag.Diagnostics, summary string, err error, schema tfsdk.Schema, fieldNames map[string]string, debug bool) {
+	validationErrors := getValidationErrors(err)
+	if len(validationErrors) == 0 {
+		diags.AddError(summary, ParseGrpcErrors(ctx, err, debug))
+		return
+	}
+	if s, ok := status.FromError(err); ok {
+		logGrpcStatus(ctx, s)
+	}
+
+	for _, e := range validationErrors {
+		if p, ok := FieldPath(schema, fieldNames, e.FieldName); ok {
+			diags.AddAttributeError(p, summary, e.ErrorMessage)
+		} else if e.FieldName != "" {
+			diags.AddError(summary, fmt.Sprintf("%s: %s", e.FieldName, e.ErrorMessage))
+		} else {
+			diags.AddError(summary, e.ErrorMessage)
+		}
+	}
+}
+
+func getValidationErrors(err error) []*errorspb.ResourceValidationError {
+	s, ok := status.FromError(err)
+	if !ok || s.Code() != codes.InvalidArgument {
+		return nil
+	}
+	var result []*errorspb.ResourceValidationError
+	for _, detail := range s.Details() {
+		if e, ok := detail.(*errorspb.ResourceValidationError); ok {
+			result = append(result, e)
+		}
+	}
+	return result
+}
+
+// FieldPath converts a field name from the server, such as rules[2].cidr_block, into the path of the matching
+// attribute in the schema. Fields have the same name as their attribute, unless they're in fieldNames, which maps
+// dot-separated fields without list indexes to the name of their attribute, e.g. "rules" to "rule". Fields mapped to ""
+// are flattened in the schema, so their own fields are looked up in the same attribute. If only a prefix of the field
+// can be found, the path to that prefix is returned instead.
+func FieldPath(schema tfsdk.Schema, fieldNames map[string]string, field string) (path.Path, bool) {
+	result := path.Empty()
+	if field == "" {
+		return result, false
+	}
+
+	attrs, blocks := schema.Attributes, schema.Blocks
+	var fieldPath []string
+	for _, segment := range strings.Split(field, ".") {
+		match := fieldSegmentRegex.FindStringSubmatch(segment)
+		if match == nil {
+			break
+		}
+		name, index := match[1], -1
+		if match[2] != "" {
+			index, _ = strconv.Atoi(match[2])
+		}
+
+		fieldPath = append(fieldPath, name)
+		if renamed, ok := fieldNames[strings.Join(fieldPath, ".")]; ok && renamed == "" {
+			continue
+		} else if ok {
+			name = renamed
+		}
+
+		if a, ok := attrs[name]; ok {
+			result = result.AtName(name)
+			attrs, blocks = nil, nil
+			if a.Attributes != nil {
+				attrs = map[string]tfsdk.Attribute{}
+				for n, nested := range a.Attributes.GetAttributes() {
+					attrs[n] = nested.(tfsdk.Attribute)
+				}
+			}
+			if index < 0 {
+				continue
+			}
+			// elements can only be addressed in lists
+			isList := false
+			if a.Attributes != nil {
+				_, isList = a.Attributes.Type().(types.ListType)
+			} else {
+				_, isList = a.Type.(types.ListType)
+			}
+			if !isList {
+				break
+			}
+			result = result.AtListIndex(index)
+		} else if b, ok := blocks[name]; ok {
+			result = result.AtName(name)
+			attrs, blocks = b.Attributes, b.Blocks
+			if index < 0 || b.NestingMode == tfsdk.BlockNestingModeSingle {
+				continue
+			}
+			if b.NestingMode != tfsdk.BlockNestingModeList {
+				break
+			}
+			result = result.AtListIndex(index)
+		} else {
+			break
+		}
+	}
+	return result, len(result.Steps()) > 0
+}
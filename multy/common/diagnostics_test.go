@@ -0,0 +1,107 @@
+package common
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/multycloud/multy/api/proto/errorspb"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+var diagnosticsTestSchema = tfsdk.Schema{
+	Attributes: map[string]tfsdk.Attribute{
+		"address": {Type: types.StringType, Optional: true},
+		"status":  {Type: types.StringType, Optional: true},
+		"ports":   {Type: types.ListType{ElemType: types.Int64Type}, Optional: true},
+		"access": {
+			Optional:   true,
+			Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{"level": {Type: types.StringType, Optional: true}}),
+		},
+	},
+	Blocks: map[string]tfsdk.Block{
+		"rule": {
+			NestingMode: tfsdk.BlockNestingModeList,
+			Attributes: map[string]tfsdk.Attribute{
+				"cidr_block": {Type: types.StringType, Optional: true},
+				"from_port":  {Type: types.Int64Type, Optional: true},
+			},
+		},
+	},
+}
+
+var diagnosticsTestFieldNames = map[string]string{"rules": "rule", "rules.port_range": "", "rules.port_range.from": "from_port"}
+
+func TestFieldPath(t *testing.T) {
+	tests := []struct {
+		field  string
+		want   path.Path
+		wantOk bool
+	}{
+		{field: "address", want: path.Root("address"), wantOk: true},
+		{field: "status", want: path.Root("status"), wantOk: true},
+		{field: "ports[1]", want: path.Root("ports").AtListIndex(1), wantOk: true},
+		{field: "access.level", want: path.Root("access").AtName("level"), wantOk: true},
+		{field: "rules[2].cidr_block", want: path.Root("rule").AtListIndex(2).AtName("cidr_block"), wantOk: true},
+		{field: "rules[0].port_range.from", want: path.Root("rule").AtListIndex(0).AtName("from_port"), wantOk: true},
+		{field: "rules[1].unknown", want: path.Root("rule").AtListIndex(1), wantOk: true},
+		// fields are never guessed, only renamed through the table
+		{field: "addresses", want: path.Empty()},
+		{field: "statu", want: path.Empty()},
+		{field: "rule[2].cidr_block", want: path.Root("rule").AtListIndex(2).AtName("cidr_block"), wantOk: true},
+		{field: "unknown", want: path.Empty()},
+		{field: "", want: path.Empty()},
+	}
+	for _, tc := range tests {
+		t.Run(tc.field, func(t *testing.T) {
+			got, ok := FieldPath(diagnosticsTestSchema, diagnosticsTestFieldNames, tc.field)
+			if ok != tc.wantOk || !got.Equal(tc.want) {
+				t.Errorf("expected %s (%t), got %s (%t)", tc.want, tc.wantOk, got, ok)
+			}
+		})
+	}
+}
+
+func TestAddGrpcErrors(t *testing.T) {
+	st, err := status.New(codes.InvalidArgument, "validation errors found").WithDetails(
+		&errorspb.ResourceValidationError{FieldName: "rules[0].cidr_block", ErrorMessage: "invalid cidr block"},
+		&errorspb.ResourceValidationError{FieldName: "unknown", ErrorMessage: "invalid field"},
+		&errorspb.ResourceValidationError{ErrorMessage: "invalid resource"},
+	)
+	if err != nil {
+		t.Fatalf("unable to create status, %s", err)
+	}
+
+	tests := []struct {
+		name string
+		err  error
+		want diag.Diagnostics
+	}{
+		{
+			name: "validation errors",
+			err:  st.Err(),
+			want: diag.Diagnostics{
+				diag.NewAttributeErrorDiagnostic(path.Root("rule").AtListIndex(0).AtName("cidr_block"), "Error creating resource", "invalid cidr block"),
+				diag.NewErrorDiagnostic("Error creating resource", "unknown: invalid field"),
+				diag.NewErrorDiagnostic("Error creating resource", "invalid resource"),
+			},
+		},
+		{
+			name: "other errors",
+			err:  status.Error(codes.NotFound, "resource not found"),
+			want: diag.Diagnostics{diag.NewErrorDiagnostic("Error creating resource", "resource not found")},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var diags diag.Diagnostics
+			AddGrpcErrors(context.Background(), &diags, "Error creating resource", tc.err, diagnosticsTestSchema, diagnosticsTestFieldNames, false)
+			if !diags.Equal(tc.want) {
+				t.Errorf("expected %v, got %v", tc.want, diags)
+			}
+		})
+	}
+}
@@ -0,0 +1,123 @@
+package common
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"path"
+	"strings"
+	"time"
+)
+
+const redacted = "<redacted>"
+
+// sensitiveFields are never logged, in any message.
+var sensitiveFields = map[protoreflect.Name]bool{
+	"password":         true,
+	"kube_config_raw":  true,
+	"user_data_base64": true,
+	"access_key":       true,
+	"secret_key":       true,
+	"session_token":    true,
+	"client_secret":    true,
+	"credentials":      true,
+}
+
+// sensitiveMetadata are the request headers that carry credentials.
+var sensitiveMetadata = map[string]bool{
+	"api_key":         true,
+	"cloud-creds-bin": true,
+}
+
+// LoggingInterceptor logs every call to the multy server at trace level, with sensitive fields redacted.
+func LoggingInterceptor() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(ctx, method, req, reply, cc, opts...)
+
+		fields := map[string]interface{}{
+			"method":     path.Base(method),
+			"latency_ms": time.Since(start).Milliseconds(),
+			"code":       status.Code(err).String(),
+			"metadata":   redactMetadata(ctx),
+			"request":    toRedactedJson(req),
+		}
+		if err == nil {
+			fields["response"] = toRedactedJson(reply)
+		} else {
+			fields["error"] = status.Convert(err).Message()
+		}
+		tflog.Trace(ctx, "multy server call", fields)
+		return err
+	}
+}
+
+func redactMetadata(ctx context.Context) map[string]string {
+	result := map[string]string{}
+	md, _ := metadata.FromOutgoingContext(ctx)
+	for key, values := range md {
+		if sensitiveMetadata[key] {
+			result[key] = redacted
+		} else {
+			result[key] = strings.Join(values, ",")
+		}
+	}
+	return result
+}
+
+func toRedactedJson(v interface{}) string {
+	m, ok := v.(proto.Message)
+	if !ok {
+		return ""
+	}
+	clone := proto.Clone(m)
+	redactMessage(clone.ProtoReflect())
+	b, err := protojson.Marshal(clone)
+	if err != nil {
+		return err.Error()
+	}
+	return string(b)
+}
+
+func redactMessage(m protoreflect.Message) {
+	var sensitive []protoreflect.FieldDescriptor
+	m.Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		switch {
+		case isSensitive(m.Descriptor(), fd):
+			sensitive = append(sensitive, fd)
+		case fd.IsList() && fd.Message() != nil:
+			for i := 0; i < v.List().Len(); i++ {
+				redactMessage(v.List().Get(i).Message())
+			}
+		case fd.IsMap() && fd.MapValue().Message() != nil:
+			v.Map().Range(func(_ protoreflect.MapKey, mv protoreflect.Value) bool {
+				redactMessage(mv.Message())
+				return true
+			})
+		case !fd.IsList() && !fd.IsMap() && fd.Message() != nil:
+			redactMessage(v.Message())
+		}
+		return true
+	})
+
+	for _, fd := range sensitive {
+		if fd.Kind() == protoreflect.StringKind && !fd.IsList() && !fd.IsMap() {
+			m.Set(fd, protoreflect.ValueOfString(redacted))
+		} else {
+			m.Clear(fd)
+		}
+	}
+}
+
+func isSensitive(md protoreflect.MessageDescriptor, fd protoreflect.FieldDescriptor) bool {
+	if sensitiveFields[fd.Name()] {
+		return true
+	}
+	// secret values are stored in a generic value field
+	return fd.Name() == "value" && strings.HasPrefix(string(md.Name()), "VaultSecret")
+}
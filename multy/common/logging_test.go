@@ -0,0 +1,101 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	"github.com/multycloud/multy/api/proto/credspb"
+	"github.com/multycloud/multy/api/proto/resourcespb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/proto"
+	"strings"
+	"testing"
+)
+
+func TestLoggingInterceptorRedactsSecrets(t *testing.T) {
+	tests := []struct {
+		name    string
+		method  string
+		req     proto.Message
+		reply   proto.Message
+		secrets []string
+	}{
+		{
+			name:    "database password",
+			method:  "/dev.multy.MultyResourceService/CreateDatabase",
+			req:     &resourcespb.CreateDatabaseRequest{Resource: &resourcespb.DatabaseArgs{Name: "db", Password: "db-secret"}},
+			reply:   &resourcespb.DatabaseResource{Name: "db", Password: "db-secret"},
+			secrets: []string{"db-secret"},
+		},
+		{
+			name:    "virtual machine user data",
+			method:  "/dev.multy.MultyResourceService/CreateVirtualMachine",
+			req:     &resourcespb.CreateVirtualMachineRequest{Resource: &resourcespb.VirtualMachineArgs{Name: "vm", UserDataBase64: "dm0tc2VjcmV0"}},
+			reply:   &resourcespb.VirtualMachineResource{Name: "vm", UserDataBase64: "dm0tc2VjcmV0"},
+			secrets: []string{"dm0tc2VjcmV0"},
+		},
+		{
+			name:    "kubernetes config",
+			method:  "/dev.multy.MultyResourceService/ReadKubernetesCluster",
+			req:     &resourcespb.ReadKubernetesClusterRequest{ResourceId: "cluster"},
+			reply:   &resourcespb.KubernetesClusterResource{Name: "cluster", KubeConfigRaw: "kube-secret"},
+			secrets: []string{"kube-secret"},
+		},
+		{
+			name:    "vault secret value",
+			method:  "/dev.multy.MultyResourceService/CreateVaultSecret",
+			req:     &resourcespb.CreateVaultSecretRequest{Resource: &resourcespb.VaultSecretArgs{Name: "secret", Value: "vault-secret"}},
+			reply:   &resourcespb.VaultSecretResource{Name: "secret", Value: "vault-secret"},
+			secrets: []string{"vault-secret"},
+		},
+		{
+			name:   "cloud credentials",
+			method: "/dev.multy.MultyResourceService/ValidateCredentials",
+			req: &credspb.CloudCredentials{
+				AwsCreds:   &credspb.AwsCredentials{AccessKey: "aws-key", SecretKey: "aws-secret", SessionToken: "aws-token"},
+				AzureCreds: &credspb.AzureCredentials{ClientId: "client", ClientSecret: "azure-secret"},
+				GcpCreds:   &credspb.GCPCredentials{Credentials: "gcp-secret", Project: "project"},
+			},
+			reply:   &credspb.CloudCredentials{},
+			secrets: []string{"aws-key", "aws-secret", "aws-token", "azure-secret", "gcp-secret"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var output bytes.Buffer
+			ctx := tflogtest.RootLogger(context.Background(), &output)
+			ctx = metadata.AppendToOutgoingContext(ctx, "api_key", "api-key-secret", "cloud-creds-bin", "creds-secret", "user-agent-extra", "visible")
+
+			invoker := func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, opts ...grpc.CallOption) error {
+				proto.Merge(reply.(proto.Message), tc.reply)
+				return nil
+			}
+			reply := tc.reply.ProtoReflect().New().Interface()
+			if err := LoggingInterceptor()(ctx, tc.method, tc.req, reply, nil, invoker); err != nil {
+				t.Fatalf("unexpected error, %s", err)
+			}
+
+			raw := output.String()
+			entries, err := tflogtest.MultilineJSONDecode(&output)
+			if err != nil {
+				t.Fatalf("unable to decode logs, %s", err)
+			}
+			if len(entries) != 1 {
+				t.Fatalf("expected a single log entry, got %d", len(entries))
+			}
+			for _, secret := range append(tc.secrets, "api-key-secret", "creds-secret") {
+				if strings.Contains(raw, secret) {
+					t.Errorf("logs contain %q:\n%s", secret, raw)
+				}
+			}
+			logged := fmt.Sprint(entries[0])
+			for _, want := range []string{redacted, "visible", "method"} {
+				if !strings.Contains(logged, want) {
+					t.Errorf("logs don't contain %q:\n%s", want, logged)
+				}
+			}
+		})
+	}
+}
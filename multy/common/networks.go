@@ -0,0 +1,112 @@
+package common
+
+import (
+	"net"
+	"sort"
+	"sync"
+)
+
+// NetworkRegistry keeps the virtual networks and subnets the provider has read or applied, so that resources referencing
+// them can be validated at plan time without calling the server. Planned values aren't registered, so validation
+// doesn't depend on the order terraform plans resources in.
+type NetworkRegistry struct {
+	mu       sync.Mutex
+	networks map[string]NetworkInfo
+	subnets  map[string]SubnetInfo
+}
+
+type NetworkInfo struct {
+	Id        string
+	CidrBlock string
+	Cloud     string
+	Location  string
+}
+
+type SubnetInfo struct {
+	Id               string
+	Name             string
+	CidrBlock        string
+	VirtualNetworkId string
+}
+
+func NewNetworkRegistry() *NetworkRegistry {
+	return &NetworkRegistry{
+		networks: map[string]NetworkInfo{},
+		subnets:  map[string]SubnetInfo{},
+	}
+}
+
+func (r *NetworkRegistry) AddNetwork(vn NetworkInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.networks[vn.Id] = vn
+}
+
+func (r *NetworkRegistry) AddSubnet(subnet SubnetInfo) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.subnets[subnet.Id] = subnet
+}
+
+func (r *NetworkRegistry) GetNetwork(id string) (NetworkInfo, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	vn, ok := r.networks[id]
+	return vn, ok
+}
+
+func (r *NetworkRegistry) GetSubnet(id string) (SubnetInfo, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	subnet, ok := r.subnets[id]
+	return subnet, ok
+}
+
+// GetSubnetNetwork returns the network of the subnet with the given id, if both are known.
+func (r *NetworkRegistry) GetSubnetNetwork(subnetId string) (NetworkInfo, bool) {
+	subnet, ok := r.GetSubnet(subnetId)
+	if !ok {
+		return NetworkInfo{}, false
+	}
+	return r.GetNetwork(subnet.VirtualNetworkId)
+}
+
+// GetOverlappingSubnets returns the known subnets in the same network whose CIDR block overlaps with the given subnet,
+// other than the subnet itself.
+func (r *NetworkRegistry) GetOverlappingSubnets(subnet SubnetInfo) []SubnetInfo {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var result []SubnetInfo
+	for id, other := range r.subnets {
+		if id == subnet.Id || other.VirtualNetworkId != subnet.VirtualNetworkId {
+			continue
+		}
+		if CidrsOverlap(subnet.CidrBlock, other.CidrBlock) {
+			result = append(result, other)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+	return result
+}
+
+// CidrContains returns true if inner is fully inside outer. Invalid CIDRs are never contained.
+func CidrContains(outer, inner string) bool {
+	_, outerNet, err := net.ParseCIDR(outer)
+	if err != nil {
+		return false
+	}
+	_, innerNet, err := net.ParseCIDR(inner)
+	if err != nil {
+		return false
+	}
+	outerOnes, outerBits := outerNet.Mask.Size()
+	innerOnes, innerBits := innerNet.Mask.Size()
+	return outerBits == innerBits && outerOnes <= innerOnes && outerNet.Contains(innerNet.IP)
+}
+
+// CidrsOverlap returns true if a and b have any address in common. Invalid CIDRs never overlap.
+func CidrsOverlap(a, b string) bool {
+	return CidrContains(a, b) || CidrContains(b, a)
+}
@@ -6,35 +6,58 @@ import (
 	"github.com/hashicorp/terraform-plugin-log/tflog"
 	mproto "github.com/multycloud/multy/api/proto"
 	"github.com/multycloud/multy/api/proto/commonpb"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
 	"golang.org/x/sync/errgroup"
 	"sync"
+	"time"
 )
 
+// RefreshCache refreshes the state of each cloud at most once every ttl. A new cache is created every time the
+// provider is configured, so refreshes are only shared by the resources of a single terraform operation.
 type RefreshCache struct {
 	cache sync.Map
+	ttl   time.Duration
+	skip  bool
 }
 
 type refreshResult struct {
 	sync.Mutex
-	done   bool
-	result error
+	refreshedAt time.Time
 }
 
-func (r *RefreshCache) Refresh(ctx context.Context, apiKey string, provider *ProviderConfig) error {
+// NewRefreshCache returns a cache that refreshes each cloud again after ttl. If skip is set, clouds are never
+// refreshed, so resources are read from the last state known by the server.
+func NewRefreshCache(ttl time.Duration, skip bool) *RefreshCache {
+	return &RefreshCache{ttl: ttl, skip: skip}
+}
+
+// Refresh refreshes the state of every cloud configured in the given account, unless it was refreshed for the same api
+// key and account less than ttl ago. If account is empty, the top-level credentials are used. Failed refreshes aren't
+// cached, so they're retried by the next read.
+func (r *RefreshCache) Refresh(ctx context.Context, apiKey string, provider *ProviderConfig, account string) error {
+	if r.skip {
+		tflog.Info(ctx, "skipping refreshing state because skip_refresh is set")
+		return nil
+	}
+	creds, ok := provider.GetAccount(account)
+	if !ok {
+		return fmt.Errorf("account %s isn't configured in the provider", account)
+	}
 	var wg errgroup.Group
-	if provider.Aws != nil {
+	if creds.Aws != nil {
 		wg.Go(func() error {
-			return r.refresh(ctx, apiKey, provider, commonpb.CloudProvider_AWS)
+			return r.refresh(ctx, apiKey, provider, commonpb.CloudProvider_AWS, account)
 		})
 	}
-	if provider.Azure != nil {
+	if creds.Azure != nil {
 		wg.Go(func() error {
-			return r.refresh(ctx, apiKey, provider, commonpb.CloudProvider_AZURE)
+			return r.refresh(ctx, apiKey, provider, commonpb.CloudProvider_AZURE, account)
 		})
 	}
-	if provider.Gcp != nil {
+	if creds.Gcp != nil {
 		wg.Go(func() error {
-			return r.refresh(ctx, apiKey, provider, commonpb.CloudProvider_GCP)
+			return r.refresh(ctx, apiKey, provider, commonpb.CloudProvider_GCP, account)
 		})
 	}
 
@@ -46,19 +69,35 @@ func (r *RefreshCache) Refresh(ctx context.Context, apiKey string, provider *Pro
 	return nil
 }
 
-func (r *RefreshCache) refresh(ctx context.Context, apiKey string, provider *ProviderConfig, cloud commonpb.CloudProvider) error {
-	value, _ := r.cache.LoadOrStore(fmt.Sprintf("%s/%s", apiKey, cloud.String()), &refreshResult{})
+func (r *RefreshCache) refresh(ctx context.Context, apiKey string, provider *ProviderConfig, cloud commonpb.CloudProvider, account string) error {
+	key := fmt.Sprintf("%s/%s", apiKey, cloud.String())
+	if account != "" {
+		key = fmt.Sprintf("%s/%s/%s", apiKey, account, cloud.String())
+	}
+	value, _ := r.cache.LoadOrStore(key, &refreshResult{})
 	result := value.(*refreshResult)
 	result.Lock()
 	defer result.Unlock()
-	if !result.done {
-		tflog.Info(ctx, fmt.Sprintf("refreshing state for %s/%s", apiKey, cloud.String()))
-		_, err := provider.Client.RefreshState(ctx, &mproto.RefreshStateRequest{Cloud: cloud})
-		result.done = true
-		result.result = err
-	} else {
-		tflog.Info(ctx, fmt.Sprintf("skipping refreshing state for %s/%s", apiKey, cloud.String()))
+	cached := !result.refreshedAt.IsZero() && time.Since(result.refreshedAt) < r.ttl
+	ctx, span := StartSpan(ctx, "RefreshState", attribute.String("cloud", cloud.String()), attribute.String("account", account), attribute.Bool("cached", cached))
+	defer span.End()
+	// the key starts with the api key, so only the cloud and account are logged
+	fields := map[string]interface{}{"cloud": cloud.String(), "account": account}
+	if cached {
+		tflog.Info(ctx, "skipping refreshing state, it was refreshed recently", fields)
+		return nil
 	}
 
-	return result.result
+	tflog.Info(ctx, "refreshing state", fields)
+	ctx, err := provider.AddHeadersForCloud(ctx, cloud, account)
+	if err != nil {
+		return err
+	}
+	_, err = provider.Client.RefreshState(ctx, &mproto.RefreshStateRequest{Cloud: cloud})
+	if err != nil {
+		span.SetStatus(codes.Error, err.Error())
+		return err
+	}
+	result.refreshedAt = time.Now()
+	return nil
 }
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"bytes"
+	"context"
+	"github.com/hashicorp/terraform-plugin-log/tflogtest"
+	mproto "github.com/multycloud/multy/api/proto"
+	"google.golang.org/grpc"
+	"strings"
+	"testing"
+	"time"
+)
+
+type refreshClient struct {
+	mproto.MultyResourceServiceClient
+	calls int
+}
+
+func (c *refreshClient) RefreshState(context.Context, *mproto.RefreshStateRequest, ...grpc.CallOption) (*mproto.RefreshStateResponse, error) {
+	c.calls++
+	return &mproto.RefreshStateResponse{}, nil
+}
+
+func TestRefreshDoesNotLogApiKey(t *testing.T) {
+	var output bytes.Buffer
+	ctx := tflogtest.RootLogger(context.Background(), &output)
+	client := &refreshClient{}
+	provider := &ProviderConfig{
+		Client:      client,
+		ApiKey:      "api-key-secret",
+		Credentials: Credentials{Aws: &AwsConfig{AccessKeyId: "key", AccessKeySecret: "secret"}},
+		Accounts:    map[string]*Credentials{"other": {Aws: &AwsConfig{AccessKeyId: "key", AccessKeySecret: "secret"}}},
+	}
+	cache := NewRefreshCache(time.Hour, false)
+	for _, account := range []string{"", "other", "other"} {
+		if err := cache.Refresh(ctx, provider.ApiKey, provider, account); err != nil {
+			t.Fatalf("unexpected error, %s", err)
+		}
+	}
+	if client.calls != 2 {
+		t.Errorf("expected 2 refreshes, got %d", client.calls)
+	}
+
+	raw := output.String()
+	if strings.Contains(raw, "api-key-secret") {
+		t.Errorf("logs contain the api key:\n%s", raw)
+	}
+	for _, want := range []string{"refreshing state", "skipping refreshing state", "AWS", "other"} {
+		if !strings.Contains(raw, want) {
+			t.Errorf("logs don't contain %q:\n%s", want, raw)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"math/rand"
+	"path"
+	"strings"
+	"time"
+)
+
+const (
+	DefaultMaxRetries       = 3
+	DefaultRetryMaxInterval = 30 * time.Second
+	retryInitialInterval    = time.Second
+)
+
+type RetryConfig struct {
+	MaxRetries  int
+	MaxInterval time.Duration
+}
+
+var retryableCodes = []codes.Code{codes.Unavailable, codes.ResourceExhausted, codes.Aborted, codes.DeadlineExceeded}
+
+// RetryInterceptor retries idempotent calls that failed with a transient error, waiting an exponentially increasing
+// and jittered amount of time between attempts.
+func RetryInterceptor(config RetryConfig) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		err := invoker(ctx, method, req, reply, cc, opts...)
+		if !isIdempotent(method) {
+			return err
+		}
+
+		for attempt := 0; attempt < config.MaxRetries && isRetryable(ctx, err); attempt++ {
+			wait := backoff(attempt, config.MaxInterval)
+			tflog.Warn(ctx, fmt.Sprintf("%s failed, retrying in %s", path.Base(method), wait), map[string]interface{}{
+				"code":    status.Code(err).String(),
+				"attempt": attempt + 1,
+			})
+
+			select {
+			case <-ctx.Done():
+				return err
+			case <-time.After(wait):
+			}
+
+			err = invoker(ctx, method, req, reply, cc, opts...)
+		}
+		return err
+	}
+}
+
+// isIdempotent returns true for methods that can be called multiple times without side effects, i.e. reads, deletes
+// and RefreshState.
+func isIdempotent(method string) bool {
+	name := path.Base(method)
+	return strings.HasPrefix(name, "Read") || strings.HasPrefix(name, "Delete") ||
+		strings.HasPrefix(name, "List") || name == "RefreshState"
+}
+
+func isRetryable(ctx context.Context, err error) bool {
+	// the deadline might have been set by the caller, in which case there's no time left to retry
+	if err == nil || ctx.Err() != nil {
+		return false
+	}
+	code := status.Code(err)
+	for _, c := range retryableCodes {
+		if code == c {
+			return true
+		}
+	}
+	return false
+}
+
+// backoff returns a random duration between half and the whole of the exponential backoff for the given attempt.
+func backoff(attempt int, maxInterval time.Duration) time.Duration {
+	d := retryInitialInterval << attempt
+	if d > maxInterval || d <= 0 {
+		d = maxInterval
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d/2)+1))
+}
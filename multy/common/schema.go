@@ -5,10 +5,10 @@ import (
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
-	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"golang.org/x/exp/slices"
 	"terraform-provider-multy/multy/mtypes"
 	"terraform-provider-multy/multy/validators"
@@ -24,20 +24,34 @@ import (
 
 var CloudsSchema = tfsdk.Attribute{
 	Type:          mtypes.CloudType,
-	Description:   fmt.Sprintf("Cloud provider to deploy resource into. Accepted values are %s", StringSliceToDocsMarkdown(mtypes.CloudType.GetAllValues())),
-	Required:      true,
+	Description:   fmt.Sprintf("Cloud provider to deploy resource into. Accepted values are %s. Defaults to the provider's `default_cloud`", StringSliceToDocsMarkdown(mtypes.CloudType.GetAllValues())),
+	Optional:      true,
+	Computed:      true,
 	Validators:    []tfsdk.AttributeValidator{validators.NewValidator(mtypes.CloudType)},
 	PlanModifiers: []tfsdk.AttributePlanModifier{resource.RequiresReplace()},
 }
 
 var LocationSchema = tfsdk.Attribute{
 	Type:          mtypes.LocationType,
-	Description:   "Location to deploy resource into. Read more about regions in [documentation](https://docs.multy.dev/regions)",
-	Required:      true,
+	Description:   "Location to deploy resource into. Read more about regions in [documentation](https://docs.multy.dev/regions). Defaults to the provider's `default_location`",
+	Optional:      true,
+	Computed:      true,
 	Validators:    []tfsdk.AttributeValidator{validators.NewValidator(mtypes.LocationType)},
 	PlanModifiers: []tfsdk.AttributePlanModifier{resource.RequiresReplace()},
 }
 
+var AccountSchema = tfsdk.Attribute{
+	Type:        types.StringType,
+	Description: "Name of the credential set in the provider's `aws_accounts`, `azure_accounts` or `gcp_accounts` used to deploy resource, depending on its cloud. Defaults to the provider's top-level credentials. Changing it replaces the resource, unless both credential sets are the same",
+	Optional:    true,
+}
+
+var DataSourceAccountSchema = tfsdk.Attribute{
+	Type:        types.StringType,
+	Description: "Name of the credential set in the provider's `aws_accounts`, `azure_accounts` or `gcp_accounts` used to read resource, depending on its cloud. Defaults to the provider's top-level credentials",
+	Optional:    true,
+}
+
 var ResourceStatusSchema = tfsdk.Attribute{
 	Description:   "Statuses of underlying created resources",
 	Type:          types.MapType{ElemType: types.StringType},
@@ -56,16 +70,29 @@ var AzureSchema = tfsdk.Attribute{
 }
 
 func RequiresReplaceIfCloudEq(replaceIfCloud ...string) tfsdk.AttributePlanModifier {
-	return validators.RequiresReplaceIf(func(ctx context.Context, state, config attr.Value, plan tfsdk.Plan) (bool, diag.Diagnostics) {
-		return requiresReplaceIfCloudEq(plan, replaceIfCloud...)
+	return validators.RequiresReplaceIf(func(ctx context.Context, state, config attr.Value, plan tfsdk.Plan, priorState tfsdk.State) (bool, diag.Diagnostics) {
+		return requiresReplaceIfCloudEq(plan, priorState, replaceIfCloud...)
 	}, fmt.Sprintf("Resource is replaced if cloud is %s", replaceIfCloud), fmt.Sprintf("Resource is replaced if cloud is %s", replaceIfCloud))
 }
 
-func requiresReplaceIfCloudEq(plan tfsdk.Plan, replaceIfCloud ...string) (bool, diag.Diagnostics) {
-	cloud := ""
-	diags := plan.GetAttribute(context.Background(), path.Root("cloud"), &cloud)
-	if diags.HasError() {
+func requiresReplaceIfCloudEq(plan tfsdk.Plan, priorState tfsdk.State, replaceIfCloud ...string) (bool, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	cloudPath := tftypes.NewAttributePath().WithAttributeName("cloud")
+	cloud, _, err := tftypes.WalkAttributePath(plan.Raw, cloudPath)
+	// cloud is unknown when it's not set in the config, until the provider's default_cloud is applied in ModifyPlan. If
+	// the default differs from the current cloud, the resource is replaced anyway.
+	if err == nil && !cloud.(tftypes.Value).IsKnown() {
+		cloud, _, err = tftypes.WalkAttributePath(priorState.Raw, cloudPath)
+	}
+	if err != nil {
+		diags.AddError("Unable to get cloud", err.Error())
+		return false, diags
+	}
+
+	var cloudStr string
+	if err := cloud.(tftypes.Value).As(&cloudStr); err != nil {
+		diags.AddError("Unable to get cloud", err.Error())
 		return false, diags
 	}
-	return slices.Contains(replaceIfCloud, cloud), nil
+	return slices.Contains(replaceIfCloud, cloudStr), nil
 }
@@ -0,0 +1,131 @@
+package common
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"terraform-provider-multy/multy/validators"
+	"time"
+)
+
+type Timeouts struct {
+	Create time.Duration
+	Read   time.Duration
+	Update time.Duration
+	Delete time.Duration
+}
+
+var DefaultTimeouts = Timeouts{
+	Create: 10 * time.Minute,
+	Read:   5 * time.Minute,
+	Update: 10 * time.Minute,
+	Delete: 10 * time.Minute,
+}
+
+// WithDefaults fills every unset timeout with its value in DefaultTimeouts.
+func (t Timeouts) WithDefaults() Timeouts {
+	if t.Create == 0 {
+		t.Create = DefaultTimeouts.Create
+	}
+	if t.Read == 0 {
+		t.Read = DefaultTimeouts.Read
+	}
+	if t.Update == 0 {
+		t.Update = DefaultTimeouts.Update
+	}
+	if t.Delete == 0 {
+		t.Delete = DefaultTimeouts.Delete
+	}
+	return t
+}
+
+// Merge overrides the timeouts with the ones set in the given timeouts block value, which might be null or empty.
+func (t Timeouts) Merge(block tftypes.Value) (Timeouts, error) {
+	if block.IsNull() || !block.IsKnown() {
+		return t, nil
+	}
+	if block.Type().Is(tftypes.List{}) {
+		var elems []tftypes.Value
+		if err := block.As(&elems); err != nil {
+			return t, err
+		}
+		if len(elems) == 0 {
+			return t, nil
+		}
+		return t.Merge(elems[0])
+	}
+	values := map[string]tftypes.Value{}
+	if err := block.As(&values); err != nil {
+		return t, err
+	}
+	for name, d := range map[string]*time.Duration{"create": &t.Create, "read": &t.Read, "update": &t.Update, "delete": &t.Delete} {
+		v, ok := values[name]
+		if !ok || v.IsNull() || !v.IsKnown() {
+			continue
+		}
+		var str string
+		if err := v.As(&str); err != nil {
+			return t, err
+		}
+		parsed, err := time.ParseDuration(str)
+		if err != nil {
+			return t, fmt.Errorf("invalid %s timeout: %w", name, err)
+		}
+		*d = parsed
+	}
+	return t, nil
+}
+
+func TimeoutsSchema(defaults Timeouts) tfsdk.Block {
+	defaults = defaults.WithDefaults()
+	timeoutAttr := func(operation string, d time.Duration) tfsdk.Attribute {
+		return tfsdk.Attribute{
+			Type:        types.StringType,
+			Description: fmt.Sprintf("Time to wait for the resource to be %s, such as `30m` or `1h`. Defaults to `%s`", operation, FormatDuration(d)),
+			Optional:    true,
+			Validators:  []tfsdk.AttributeValidator{validators.IsDurationValidator{}},
+		}
+	}
+	// single nested blocks are sent as null when they aren't set, which the framework can't plan, so a list with at
+	// most one element is used instead
+	return tfsdk.Block{
+		Description: "Timeouts for the operations on this resource",
+		NestingMode: tfsdk.BlockNestingModeList,
+		MaxItems:    1,
+		Attributes: map[string]tfsdk.Attribute{
+			"create": timeoutAttr("created", defaults.Create),
+			"read":   timeoutAttr("read", defaults.Read),
+			"update": timeoutAttr("updated", defaults.Update),
+			"delete": timeoutAttr("deleted", defaults.Delete),
+		},
+	}
+}
+
+// IsTimeout returns true if the operation failed because ctx reached its deadline.
+func IsTimeout(ctx context.Context) bool {
+	return errors.Is(ctx.Err(), context.DeadlineExceeded)
+}
+
+// TimeoutErrorMessage explains how to increase the timeout of an operation (create, read, update or delete) that
+// didn't finish in time.
+func TimeoutErrorMessage(operation string, resourceType string, timeout time.Duration) string {
+	return fmt.Sprintf("Couldn't %s %s within %s. The operation might still be in progress in the Multy server. "+
+		"If it usually takes longer, increase the timeout in the timeouts block, for example:\n\n"+
+		"  timeouts {\n    %s = \"%s\"\n  }",
+		operation, resourceType, FormatDuration(timeout), operation, FormatDuration(2*timeout))
+}
+
+// FormatDuration prints d in the shortest unit that represents it, e.g. 1h instead of 1h0m0s.
+func FormatDuration(d time.Duration) string {
+	switch {
+	case d%time.Hour == 0:
+		return fmt.Sprintf("%dh", d/time.Hour)
+	case d%time.Minute == 0:
+		return fmt.Sprintf("%dm", d/time.Minute)
+	default:
+		return d.String()
+	}
+}
@@ -0,0 +1,28 @@
+package common
+
+import "sync"
+
+// TypeRegistry keeps the type of every resource the provider has read or applied, so that data source lookups only read
+// the listed resources that can be of the type they're looking for.
+type TypeRegistry struct {
+	mu    sync.Mutex
+	types map[string]string
+}
+
+func NewTypeRegistry() *TypeRegistry {
+	return &TypeRegistry{types: map[string]string{}}
+}
+
+func (r *TypeRegistry) Add(id string, typeName string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.types[id] = typeName
+}
+
+// Get returns the type of the resource with the given id, such as multy_subnet, if it's known.
+func (r *TypeRegistry) Get(id string) (string, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	typeName, ok := r.types[id]
+	return typeName, ok
+}
@@ -1,18 +1,20 @@
 package common
 
 import (
+	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/multycloud/multy/api/proto/errorspb"
 	"github.com/multycloud/multy/api/proto/resourcespb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
 	"strings"
 )
 
-const DebugMode = true
-
 func StringToRuleDirection(dir string) resourcespb.Direction {
 	if strings.EqualFold(dir, "both") {
 		dir = "both_directions"
@@ -27,11 +29,14 @@ func RuleDirectionToString(dir resourcespb.Direction) string {
 	return strings.ToLower(dir.String())
 }
 
-func ParseGrpcErrors(err error) string {
+// ParseGrpcErrors returns a user-friendly message for an error returned by the multy server. Internal error details
+// and any detail not otherwise shown are only included if debug is set, but they are always logged at debug level.
+func ParseGrpcErrors(ctx context.Context, err error, debug bool) string {
 	s, ok := status.FromError(err)
 	if !ok {
 		return err.Error()
 	}
+	logGrpcStatus(ctx, s)
 
 	str := ""
 
@@ -41,14 +46,18 @@ func ParseGrpcErrors(err error) string {
 		for _, detail := range s.Details() {
 			if e, ok := detail.(*errorspb.ResourceValidationError); ok {
 				str += "\n" + e.ErrorMessage
+			} else if debug {
+				str += "\n" + formatDetail(detail)
 			}
 		}
 	} else if s.Code() == codes.Internal {
 		str += "something went wrong: " + s.Message()
-		if DebugMode {
+		if debug {
 			for _, detail := range s.Details() {
 				if e, ok := detail.(*errorspb.InternalErrorDetails); ok {
 					str += "\n" + e.ErrorMessage
+				} else {
+					str += "\n" + formatDetail(detail)
 				}
 			}
 		}
@@ -57,11 +66,13 @@ func ParseGrpcErrors(err error) string {
 		for _, detail := range s.Details() {
 			if e, ok := detail.(*errorspb.DeploymentErrorDetails); ok {
 				str += "\n" + e.ErrorMessage
+			} else if debug {
+				str += "\n" + formatDetail(detail)
 			}
 		}
 	} else if s.Code() == codes.Unavailable {
 		str = "Server is unavailable. Please try again in a few minutes.\n"
-		if DebugMode {
+		if debug {
 			str += s.Message()
 		}
 	} else if s.Code() == codes.NotFound {
@@ -75,26 +86,63 @@ func ParseGrpcErrors(err error) string {
 
 }
 
+// logGrpcStatus logs the code, message and every detail of s, so that they can be attached to support tickets.
+func logGrpcStatus(ctx context.Context, s *status.Status) {
+	var details []string
+	for _, detail := range s.Details() {
+		details = append(details, formatDetail(detail))
+	}
+	tflog.Debug(ctx, "multy server returned an error", map[string]interface{}{
+		"code":    s.Code().String(),
+		"message": s.Message(),
+		"details": details,
+	})
+}
+
+// formatDetail returns the type and the JSON encoding of a status detail.
+func formatDetail(detail any) string {
+	m, ok := detail.(proto.Message)
+	if !ok {
+		// details that can't be decoded are returned as errors
+		return fmt.Sprintf("%v", detail)
+	}
+	b, err := protojson.Marshal(m)
+	if err != nil {
+		return fmt.Sprintf("%s: %v", proto.MessageName(m), m)
+	}
+	return fmt.Sprintf("%s: %s", proto.MessageName(m), b)
+}
+
+// DefaultToNull returns a null value if t is the zero value of its type, so that fields not set by the server are
+// shown as null instead of "" or 0.
 func DefaultToNull[OutT attr.Value](t any) OutT {
 	var s attr.Value
-	switch t.(type) {
+	switch v := t.(type) {
 	case string:
-		if t.(string) == "" {
+		if v == "" {
 			s = types.StringNull()
 		} else {
-			s = types.StringValue(t.(string))
-		}
-	case int, int64, int32:
-
-		if t.(int64) == 0 {
-			s = types.Int64Null()
-		} else {
-			s = types.Int64Value(t.(int64))
+			s = types.StringValue(v)
 		}
+	case int:
+		s = int64ToNull(int64(v))
+	case int32:
+		s = int64ToNull(int64(v))
+	case int64:
+		s = int64ToNull(v)
+	default:
+		panic(fmt.Sprintf("DefaultToNull doesn't support values of type %T", t))
 	}
 	return s.(OutT)
 }
 
+func int64ToNull(v int64) types.Int64 {
+	if v == 0 {
+		return types.Int64Null()
+	}
+	return types.Int64Value(v)
+}
+
 func NullToDefault[OutT any, T attr.Value](t T) OutT {
 	returnVal := new(OutT)
 	value, err := t.ToTerraformValue(nil)
@@ -0,0 +1,169 @@
+package multy
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"google.golang.org/protobuf/encoding/prototext"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"math/rand"
+	"terraform-provider-multy/multy/common"
+	"testing"
+)
+
+// roundTripSeeds is the number of random resources converted by each round trip test.
+const roundTripSeeds = 100
+
+func TestConvertRoundTrip(t *testing.T) {
+	t.Run("database", roundTripTest(ResourceDatabaseType{}, convertToDatabase, convertFromDatabase))
+	t.Run("kubernetes_cluster", roundTripTest(ResourceKubernetesClusterType{}, convertToKubernetesCluster, convertFromKubernetesCluster))
+	t.Run("kubernetes_node_pool", roundTripTest(ResourceKubernetesNodePoolType{}, convertToKubernetesNodePool, convertFromKubernetesNodePool))
+	t.Run("network_interface", roundTripTest(ResourceNetworkInterfaceType{}, convertToNetworkInterface, convertFromNetworkInterface))
+	t.Run("network_interface_security_group_association", roundTripTest(ResourceNetworkInterfaceSecurityGroupAssociationType{}, convertToNetworkInterfaceSecurityGroupAssociation, convertFromNetworkInterfaceSecurityGroupAssociation))
+	t.Run("network_security_group", roundTripTest(ResourceNetworkSecurityGroupType{}, convertToNetworkSecurityGroup, convertFromNetworkSecurityGroup))
+	t.Run("object_storage", roundTripTest(ResourceObjectStorageType{}, convertToObjectStorage, convertFromObjectStorage))
+	t.Run("object_storage_object", roundTripTest(ResourceObjectStorageObjectType{}, convertToObjectStorageObject, convertFromObjectStorageObject))
+	t.Run("public_ip", roundTripTest(ResourcePublicIpType{}, convertToPublicIp, convertFromPublicIp))
+	t.Run("route_table", roundTripTest(ResourceRouteTableType{}, convertToRouteTable, convertFromRouteTable))
+	t.Run("route_table_association", roundTripTest(ResourceRouteTableAssociationType{}, convertToRouteTableAssociation, convertFromRouteTableAssociation))
+	t.Run("subnet", roundTripTest(ResourceSubnetType{}, convertToSubnet, convertFromSubnet))
+	t.Run("vault", roundTripTest(ResourceVaultType{}, convertToVault, convertFromVault))
+	t.Run("vault_access_policy", roundTripTest(ResourceVaultAccessPolicyType{}, convertToVaultAccessPolicy, convertFromVaultAccessPolicy))
+	t.Run("vault_secret", roundTripTest(ResourceVaultSecretType{}, convertToVaultSecret, convertFromVaultSecret))
+	t.Run("virtual_machine", roundTripTest(ResourceVirtualMachineType{}, convertToVirtualMachine, convertFromVirtualMachine))
+	t.Run("virtual_network", roundTripTest(ResourceVirtualNetworkType{}, convertToVirtualNetwork, convertFromVirtualNetwork))
+}
+
+// roundTripTest converts random resources returned by the server into terraform values and back into args, checking
+// that the values conform to the schema and that every field that is both in the resource and the args is kept.
+func roundTripTest[R protobuf.Message, T any, A protobuf.Message](resourceType resourceType, convertTo func(R) T, convertFrom func(T) A) func(t *testing.T) {
+	return func(t *testing.T) {
+		ctx := context.Background()
+		schema := resourceType.NewResource(ctx, &Provider{}).(MultyResource[T]).schema
+		for seed := int64(0); seed < roundTripSeeds; seed++ {
+			res := newMessage[R]()
+			randomMessage(rand.New(rand.NewSource(seed)), res.ProtoReflect(), 0)
+
+			state := tfsdk.State{Schema: schema}
+			if diags := state.Set(ctx, convertTo(res)); diags.HasError() {
+				t.Fatalf("seed %d: converted resource doesn't conform to the schema: %s: %s\nresource: %s",
+					seed, diags[0].Summary(), diags[0].Detail(), prototext.Format(res))
+			}
+			// plans are decoded from terraform values, so the args are built from the decoded resource
+			var decoded T
+			if diags := state.Get(ctx, &decoded); diags.HasError() {
+				t.Fatalf("seed %d: unable to decode state: %s: %s", seed, diags[0].Summary(), diags[0].Detail())
+			}
+
+			args := convertFrom(decoded)
+			want := newMessage[A]()
+			copyFields(want.ProtoReflect(), res.ProtoReflect())
+			if !protobuf.Equal(want, args) {
+				t.Fatalf("seed %d: args don't match the resource\nresource: %s\nwant: %s\ngot: %s",
+					seed, prototext.Format(res), prototext.Format(want), prototext.Format(args))
+			}
+		}
+	}
+}
+
+// TestDefaultToNull checks the int types that aren't covered by the round trips, as the converters always pass int64.
+func TestDefaultToNull(t *testing.T) {
+	for _, v := range []any{int(0), int32(0), int64(0)} {
+		if got := common.DefaultToNull[types.Int64](v); !got.IsNull() {
+			t.Errorf("DefaultToNull(%T(0)) = %s, want null", v, got)
+		}
+	}
+	for _, v := range []any{int(7), int32(7), int64(7)} {
+		if got := common.DefaultToNull[types.Int64](v); got.ValueInt64() != 7 {
+			t.Errorf("DefaultToNull(%T(7)) = %s, want 7", v, got)
+		}
+	}
+}
+
+type resourceType interface {
+	NewResource(ctx context.Context, p provider.Provider) resource.Resource
+}
+
+// requiredMessages are always returned by the server, so they're never left empty in random resources.
+var requiredMessages = map[protoreflect.Name]bool{
+	"common_parameters": true,
+	"default_node_pool": true,
+	"image_reference":   true,
+	"port_range":        true,
+}
+
+// randomMessage sets every field of m to a random value. Nested messages are only set up to a few levels deep.
+func randomMessage(r *rand.Rand, m protoreflect.Message, depth int) {
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.ContainingOneof() != nil && m.WhichOneof(fd.ContainingOneof()) != nil {
+			continue
+		}
+		switch {
+		case fd.IsList():
+			list := m.Mutable(fd).List()
+			for n := r.Intn(4); n > 0; n-- {
+				if fd.Message() != nil {
+					if depth >= 3 {
+						break
+					}
+					elem := list.NewElement()
+					randomMessage(r, elem.Message(), depth+1)
+					list.Append(elem)
+				} else {
+					list.Append(randomScalar(r, fd))
+				}
+			}
+		case fd.IsMap():
+			if fd.MapValue().Message() != nil {
+				continue
+			}
+			mapValue := m.Mutable(fd).Map()
+			for n := r.Intn(3); n > 0; n-- {
+				mapValue.Set(randomScalar(r, fd.MapKey()).MapKey(), randomScalar(r, fd.MapValue()))
+			}
+		case fd.Message() != nil:
+			if requiredMessages[fd.Name()] || (depth < 3 && r.Intn(5) > 0) {
+				randomMessage(r, m.Mutable(fd).Message(), depth+1)
+			}
+		default:
+			m.Set(fd, randomScalar(r, fd))
+		}
+	}
+}
+
+func randomScalar(r *rand.Rand, fd protoreflect.FieldDescriptor) protoreflect.Value {
+	switch fd.Kind() {
+	case protoreflect.StringKind:
+		return protoreflect.ValueOfString(fmt.Sprintf("%s-%d", fd.Name(), r.Intn(1000)))
+	case protoreflect.BytesKind:
+		return protoreflect.ValueOfBytes([]byte(fmt.Sprintf("%s-%d", fd.Name(), r.Intn(1000))))
+	case protoreflect.BoolKind:
+		return protoreflect.ValueOfBool(r.Intn(2) == 1)
+	case protoreflect.EnumKind:
+		// the zero value of enums means unknown, which the server doesn't return
+		values := fd.Enum().Values()
+		if values.Len() == 1 {
+			return protoreflect.ValueOfEnum(values.Get(0).Number())
+		}
+		return protoreflect.ValueOfEnum(values.Get(1 + r.Intn(values.Len()-1)).Number())
+	case protoreflect.Int32Kind, protoreflect.Sint32Kind, protoreflect.Sfixed32Kind:
+		return protoreflect.ValueOfInt32(int32(r.Intn(1000)))
+	case protoreflect.Int64Kind, protoreflect.Sint64Kind, protoreflect.Sfixed64Kind:
+		return protoreflect.ValueOfInt64(int64(r.Intn(1000)))
+	case protoreflect.Uint32Kind, protoreflect.Fixed32Kind:
+		return protoreflect.ValueOfUint32(uint32(r.Intn(1000)))
+	case protoreflect.Uint64Kind, protoreflect.Fixed64Kind:
+		return protoreflect.ValueOfUint64(uint64(r.Intn(1000)))
+	case protoreflect.FloatKind:
+		return protoreflect.ValueOfFloat32(float32(r.Intn(1000)) / 4)
+	case protoreflect.DoubleKind:
+		return protoreflect.ValueOfFloat64(float64(r.Intn(1000)) / 4)
+	}
+	panic(fmt.Sprintf("unsupported field kind %s", fd.Kind()))
+}
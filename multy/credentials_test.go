@@ -0,0 +1,77 @@
+package multy
+
+import (
+	"encoding/json"
+	"github.com/multycloud/multy/api/proto/credspb"
+	"os"
+	"strings"
+	"testing"
+)
+
+// TestChildResourceCredentials checks that resources without a cloud attribute only send the credentials of their
+// parent's cloud.
+func TestChildResourceCredentials(t *testing.T) {
+	for _, name := range []string{
+		"subnet",
+		"route_table",
+		"route_table_association",
+		"network_interface_security_group_association",
+		"vault_secret",
+		"vault_access_policy",
+		"object_storage_object",
+		"kubernetes_node_pool",
+	} {
+		name := name
+		t.Run(name, func(t *testing.T) {
+			b, err := os.ReadFile("../tests/plans/" + name + ".json")
+			if err != nil {
+				t.Fatalf("unable to open test file, %s", err)
+			}
+			var test planTest
+			if err := json.Unmarshal(b, &test); err != nil {
+				t.Fatalf("unable to parse test file, %s", err)
+			}
+
+			for _, cloud := range test.Clouds {
+				h := newPlanHarness(t, cloud)
+				for _, dep := range test.Dependencies {
+					h.apply(dep.Address, dep.Config)
+				}
+				h.apply(test.Resource.Address, test.Resource.Config)
+				if errs := diagnosticErrors(h.read(test.Resource.Address)); errs != "" {
+					t.Fatalf("unable to read %s, %s", test.Resource.Address, errs)
+				}
+
+				for _, method := range []string{"Create" + methodName(name), "Read" + methodName(name)} {
+					if got := sentClouds(h.fake.getCreds(method)); got != cloud {
+						t.Errorf("%s: expected only %s credentials to be sent, got %q", method, cloud, got)
+					}
+				}
+			}
+		})
+	}
+}
+
+// methodName returns the name used by the server's methods for the given resource type, such as RouteTable for
+// route_table.
+func methodName(name string) string {
+	parts := strings.Split(name, "_")
+	for i, p := range parts {
+		parts[i] = strings.ToUpper(p[:1]) + p[1:]
+	}
+	return strings.Join(parts, "")
+}
+
+func sentClouds(creds *credspb.CloudCredentials) string {
+	var clouds []string
+	if creds.GetAwsCreds() != nil {
+		clouds = append(clouds, "aws")
+	}
+	if creds.GetAzureCreds() != nil {
+		clouds = append(clouds, "azure")
+	}
+	if creds.GetGcpCreds() != nil {
+		clouds = append(clouds, "gcp")
+	}
+	return strings.Join(clouds, ",")
+}
@@ -0,0 +1,411 @@
+package multy
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/multycloud/multy/api/proto"
+	"golang.org/x/sync/errgroup"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"sort"
+	"strings"
+	"terraform-provider-multy/multy/common"
+)
+
+// lookupAttributes can be used to find a resource when its id isn't known, if the data source has them.
+var lookupAttributes = []string{"name", "cloud", "location", "resource_group_id", "virtual_network_id", "subnet_id"}
+
+// lookupConcurrency is the maximum number of resources read at the same time while looking up a data source.
+const lookupConcurrency = 8
+
+type MultyDataSource[T any] struct {
+	p        Provider
+	readFunc func(ctx context.Context, p Provider, state T) (T, error)
+	name     string
+	schema   tfsdk.Schema
+}
+
+func (d MultyDataSource[T]) Read(ctx context.Context, req datasource.ReadRequest, resp *datasource.ReadResponse) {
+	ctx, span := common.StartSpan(ctx, "data."+d.name+".Read")
+	defer func() { common.EndSpan(span, resp.Diagnostics) }()
+
+	if !d.p.Configured {
+		resp.Diagnostics.AddError(
+			"Provider not configured",
+			"The provider hasn't been configured before reading data sources, likely because it depends on an unknown value from another resource.",
+		)
+		return
+	}
+
+	var id types.String
+	resp.Diagnostics.Append(req.Config.GetAttribute(ctx, path.Root("id"), &id)...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	filters, err := d.getFilters(req.Config)
+	if err != nil {
+		resp.Diagnostics.AddError("Error reading data source config", err.Error())
+		return
+	}
+	if id.IsNull() && len(filters) == 0 {
+		resp.Diagnostics.AddError(
+			"Missing data source arguments",
+			fmt.Sprintf("Either id or at least one of %s must be set.", strings.Join(d.lookupAttributes(), ", ")),
+		)
+		return
+	}
+
+	c := d.p.Client
+	ctx, diags := addHeaders(ctx, c, req.Config.Raw)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, common.DefaultTimeouts.Read)
+	defer cancel()
+	err = c.RefreshCache.Refresh(ctx, c.ApiKey, c, getAccount(req.Config.Raw))
+	if err != nil && common.IsTimeout(ctx) {
+		resp.Diagnostics.AddError("Timeout refreshing resource", fmt.Sprintf("Couldn't read %s within %s.", d.name, common.FormatDuration(common.DefaultTimeouts.Read)))
+		return
+	} else if err != nil {
+		resp.Diagnostics.AddError("Error refreshing resource", common.ParseGrpcErrors(ctx, err, d.p.Client.Debug))
+		return
+	}
+
+	if id.IsNull() {
+		state, diags := d.lookup(ctx, filters)
+		resp.Diagnostics.Append(diags...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+		d.register(state)
+		resp.Diagnostics.Append(d.set(ctx, &resp.State.Raw, state, req.Config.Raw)...)
+		registerResource(c, d.name, resp.State.Raw)
+		return
+	}
+
+	state, err := d.readFunc(ctx, d.p, newWithId[T](id.ValueString()))
+	if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+		resp.Diagnostics.AddAttributeError(
+			path.Root("id"),
+			"Resource not found",
+			fmt.Sprintf("Resource with id %s doesn't exist.", id.ValueString()),
+		)
+		return
+	} else if err != nil {
+		resp.Diagnostics.AddError("Error reading resource", common.ParseGrpcErrors(ctx, err, d.p.Client.Debug))
+		return
+	}
+
+	d.register(state)
+	resp.Diagnostics.Append(d.set(ctx, &resp.State.Raw, state, req.Config.Raw)...)
+	registerResource(c, d.name, resp.State.Raw)
+}
+
+// set encodes value into raw, keeping the account from config since the server doesn't return it.
+func (d MultyDataSource[T]) set(ctx context.Context, raw *tftypes.Value, value T, config tftypes.Value) diag.Diagnostics {
+	state := tfsdk.State{Schema: d.schema}
+	diags := state.Set(ctx, value)
+	if diags.HasError() {
+		return diags
+	}
+
+	values := map[string]tftypes.Value{}
+	if err := state.Raw.As(&values); err != nil {
+		diags.AddError("Unable to encode resource", err.Error())
+		return diags
+	}
+	configValues := map[string]tftypes.Value{}
+	if err := config.As(&configValues); err != nil {
+		diags.AddError("Unable to parse data source config", err.Error())
+		return diags
+	}
+	values["account"] = configValues["account"]
+	*raw = tftypes.NewValue(d.fullSchema().Type().TerraformType(ctx), values)
+	return diags
+}
+
+// register records state in the provider's network registry, so resources can be validated against it.
+func (d MultyDataSource[T]) register(state T) {
+	if n, ok := (any(state)).(networkRegistrant); ok && d.p.Client.Networks != nil {
+		n.register(d.p.Client.Networks)
+	}
+}
+
+// isOtherResourceType returns true if err means the resource read by a data source has a different type, which the
+// server reports as not found.
+func isOtherResourceType(err error) bool {
+	return err != nil && status.Code(err) == codes.NotFound
+}
+
+// lookupCandidates returns the listed ids that can be of the data source's type. The server only lists ids, so every
+// resource has to be read unless the provider already knows its type from reading or applying it.
+func (d MultyDataSource[T]) lookupCandidates(ids []string) []string {
+	if d.p.Client.Types == nil {
+		return ids
+	}
+	var result []string
+	for _, id := range ids {
+		if typeName, ok := d.p.Client.Types.Get(id); ok && typeName != d.name {
+			continue
+		}
+		result = append(result, id)
+	}
+	return result
+}
+
+// lookup goes through every resource the user has and returns the only one that matches all the given filters.
+func (d MultyDataSource[T]) lookup(ctx context.Context, filters map[string]tftypes.Value) (T, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	var result T
+
+	list, err := d.p.Client.Client.ListResources(ctx, &proto.ListResourcesRequest{})
+	if err != nil {
+		diags.AddError("Error listing resources", common.ParseGrpcErrors(ctx, err, d.p.Client.Debug))
+		return result, diags
+	}
+
+	ids := d.lookupCandidates(list.Resources)
+	// resources are read in parallel, but results are kept in the order they were listed so lookups are deterministic
+	candidates := make([]*T, len(ids))
+	errs := make([]error, len(ids))
+	g, gctx := errgroup.WithContext(ctx)
+	sem := make(chan struct{}, lookupConcurrency)
+	for i, resourceId := range ids {
+		i, resourceId := i, resourceId
+		g.Go(func() error {
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-gctx.Done():
+				return nil
+			}
+			candidate, err := d.readFunc(gctx, d.p, newWithId[T](resourceId))
+			if isOtherResourceType(err) {
+				tflog.Debug(ctx, "Skipping resource", map[string]interface{}{"resource_id": resourceId, "error": err.Error()})
+				return nil
+			} else if err != nil {
+				errs[i] = err
+				return err
+			}
+			if d.p.Client.Types != nil {
+				d.p.Client.Types.Add(resourceId, d.name)
+			}
+			candidates[i] = &candidate
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		if common.IsTimeout(ctx) {
+			diags.AddError("Timeout looking up resource", fmt.Sprintf("Couldn't look up %s within %s.", d.name, common.FormatDuration(common.DefaultTimeouts.Read)))
+			return result, diags
+		}
+		// only the first error is reported, the others are likely reads cancelled because of it
+		for i := range errs {
+			if errs[i] == err {
+				diags.AddError(
+					"Error reading resource",
+					fmt.Sprintf("Unable to read resource %s while looking up %s: %s", ids[i], d.name, common.ParseGrpcErrors(ctx, err, d.p.Client.Debug)),
+				)
+			}
+		}
+		return result, diags
+	}
+
+	var matches []string
+	for i, candidate := range candidates {
+		if candidate == nil {
+			continue
+		}
+		ok, err := d.matches(ctx, *candidate, filters)
+		if err != nil {
+			diags.AddError("Error filtering resources", err.Error())
+			return result, diags
+		}
+		if ok {
+			matches = append(matches, ids[i])
+			result = *candidate
+		}
+	}
+
+	switch len(matches) {
+	case 0:
+		diags.AddError(
+			"No matching resource found",
+			fmt.Sprintf("No %s matches %s.", d.name, describeFilters(filters)),
+		)
+	case 1:
+	default:
+		diags.AddError(
+			"Multiple matching resources found",
+			fmt.Sprintf("%d resources of type %s match %s: %s. Use more specific filters or the id attribute instead.",
+				len(matches), d.name, describeFilters(filters), strings.Join(matches, ", ")),
+		)
+	}
+	return result, diags
+}
+
+// getFilters returns the lookup attributes that are set in the config.
+func (d MultyDataSource[T]) getFilters(config tfsdk.Config) (map[string]tftypes.Value, error) {
+	values := map[string]tftypes.Value{}
+	if err := config.Raw.As(&values); err != nil {
+		return nil, err
+	}
+	filters := map[string]tftypes.Value{}
+	for _, attrName := range d.lookupAttributes() {
+		if v, ok := values[attrName]; ok && !v.IsNull() {
+			filters[attrName] = v
+		}
+	}
+	return filters, nil
+}
+
+func (d MultyDataSource[T]) matches(ctx context.Context, candidate T, filters map[string]tftypes.Value) (bool, error) {
+	state := tfsdk.State{Schema: d.schema}
+	if diags := state.Set(ctx, candidate); diags.HasError() {
+		return false, fmt.Errorf("unable to convert resource: %s", diags[0].Detail())
+	}
+	values := map[string]tftypes.Value{}
+	if err := state.Raw.As(&values); err != nil {
+		return false, err
+	}
+	for attrName, filter := range filters {
+		if !filter.Equal(values[attrName]) {
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+func (d MultyDataSource[T]) lookupAttributes() []string {
+	var result []string
+	for _, attrName := range lookupAttributes {
+		if _, ok := d.schema.Attributes[attrName]; ok {
+			result = append(result, attrName)
+		}
+	}
+	return result
+}
+
+func describeFilters(filters map[string]tftypes.Value) string {
+	var result []string
+	for attrName, v := range filters {
+		var s string
+		if err := v.As(&s); err != nil {
+			s = v.String()
+		}
+		result = append(result, fmt.Sprintf("%s = %q", attrName, s))
+	}
+	sort.Strings(result)
+	return strings.Join(result, ", ")
+}
+
+func (d MultyDataSource[T]) Metadata(_ context.Context, _ datasource.MetadataRequest, resp *datasource.MetadataResponse) {
+	resp.TypeName = d.name
+}
+
+func (d MultyDataSource[T]) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
+	return d.fullSchema(), nil
+}
+
+// fullSchema returns the data source schema with the account attribute, which isn't part of the resource.
+func (d MultyDataSource[T]) fullSchema() tfsdk.Schema {
+	s := d.schema
+	s.Attributes = map[string]tfsdk.Attribute{"account": common.DataSourceAccountSchema}
+	for name, a := range d.schema.Attributes {
+		s.Attributes[name] = a
+	}
+	return s
+}
+
+// dataSourceSchema derives a read-only data source schema from a resource schema. Every attribute becomes computed
+// except for id and lookup attributes, which can be set to find the resource, and blocks are turned into computed
+// nested attributes since data sources can't have computed blocks.
+func dataSourceSchema(s tfsdk.Schema, description string) tfsdk.Schema {
+	attrs := map[string]tfsdk.Attribute{}
+	for name, a := range s.Attributes {
+		attrs[name] = computedAttribute(a)
+	}
+	for name, b := range s.Blocks {
+		attrs[name] = computedBlock(b)
+	}
+	for _, attrName := range lookupAttributes {
+		if a, ok := attrs[attrName]; ok {
+			a.Optional = true
+			attrs[attrName] = a
+		}
+	}
+	attrs["id"] = tfsdk.Attribute{
+		Type:        types.StringType,
+		Description: "Multy id of the resource to read. If not set, the resource is looked up by the other arguments and exactly one resource must match",
+		Optional:    true,
+		Computed:    true,
+	}
+
+	return tfsdk.Schema{
+		MarkdownDescription: description,
+		Attributes:          attrs,
+	}
+}
+
+func computedAttribute(a tfsdk.Attribute) tfsdk.Attribute {
+	result := tfsdk.Attribute{
+		Type:                a.Type,
+		Description:         a.Description,
+		MarkdownDescription: a.MarkdownDescription,
+		Sensitive:           a.Sensitive,
+		Computed:            true,
+	}
+	if a.Attributes != nil {
+		nested := map[string]tfsdk.Attribute{}
+		for name, n := range a.Attributes.GetAttributes() {
+			nested[name] = computedAttribute(n.(tfsdk.Attribute))
+		}
+		// the nesting mode isn't exported, so it's inferred from the type of the nested attributes
+		switch a.Attributes.Type().(type) {
+		case types.SetType:
+			result.Attributes = tfsdk.SetNestedAttributes(nested)
+		case types.MapType:
+			result.Attributes = tfsdk.MapNestedAttributes(nested)
+		case types.ListType:
+			result.Attributes = tfsdk.ListNestedAttributes(nested)
+		default:
+			result.Attributes = tfsdk.SingleNestedAttributes(nested)
+		}
+	}
+	return result
+}
+
+func computedBlock(b tfsdk.Block) tfsdk.Attribute {
+	nested := map[string]tfsdk.Attribute{}
+	for name, a := range b.Attributes {
+		nested[name] = computedAttribute(a)
+	}
+	for name, n := range b.Blocks {
+		nested[name] = computedBlock(n)
+	}
+
+	result := tfsdk.Attribute{
+		Description:         b.Description,
+		MarkdownDescription: b.MarkdownDescription,
+		Computed:            true,
+	}
+	switch b.NestingMode {
+	case tfsdk.BlockNestingModeSet:
+		result.Attributes = tfsdk.SetNestedAttributes(nested)
+	case tfsdk.BlockNestingModeSingle:
+		result.Attributes = tfsdk.SingleNestedAttributes(nested)
+	default:
+		result.Attributes = tfsdk.ListNestedAttributes(nested)
+	}
+	return result
+}
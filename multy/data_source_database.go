@@ -0,0 +1,18 @@
+package multy
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+)
+
+type DataSourceDatabaseType struct{}
+
+func (r DataSourceDatabaseType) NewDataSource(_ context.Context, p provider.Provider) datasource.DataSource {
+	return MultyDataSource[Database]{
+		p:        *(p.(*Provider)),
+		readFunc: readDatabase,
+		name:     "multy_database",
+		schema:   dataSourceSchema(databaseSchema, "Provides an existing Multy Database"),
+	}
+}
@@ -0,0 +1,18 @@
+package multy
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+)
+
+type DataSourceKubernetesClusterType struct{}
+
+func (r DataSourceKubernetesClusterType) NewDataSource(_ context.Context, p provider.Provider) datasource.DataSource {
+	return MultyDataSource[KubernetesCluster]{
+		p:        *(p.(*Provider)),
+		readFunc: readKubernetesCluster,
+		name:     "multy_kubernetes_cluster",
+		schema:   dataSourceSchema(kubernetesClusterSchema, "Provides an existing Multy Kubernetes Cluster"),
+	}
+}
@@ -0,0 +1,18 @@
+package multy
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+)
+
+type DataSourceNetworkSecurityGroupType struct{}
+
+func (r DataSourceNetworkSecurityGroupType) NewDataSource(_ context.Context, p provider.Provider) datasource.DataSource {
+	return MultyDataSource[NetworkSecurityGroup]{
+		p:        *(p.(*Provider)),
+		readFunc: readNetworkSecurityGroup,
+		name:     "multy_network_security_group",
+		schema:   dataSourceSchema(nsgSchema, "Provides an existing Multy Network Security Group"),
+	}
+}
@@ -0,0 +1,18 @@
+package multy
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+)
+
+type DataSourceObjectStorageType struct{}
+
+func (r DataSourceObjectStorageType) NewDataSource(_ context.Context, p provider.Provider) datasource.DataSource {
+	return MultyDataSource[ObjectStorage]{
+		p:        *(p.(*Provider)),
+		readFunc: readObjectStorage,
+		name:     "multy_object_storage",
+		schema:   dataSourceSchema(objectStorageSchema, "Provides an existing Multy Object Storage"),
+	}
+}
@@ -0,0 +1,18 @@
+package multy
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+)
+
+type DataSourcePublicIpType struct{}
+
+func (r DataSourcePublicIpType) NewDataSource(_ context.Context, p provider.Provider) datasource.DataSource {
+	return MultyDataSource[PublicIp]{
+		p:        *(p.(*Provider)),
+		readFunc: readPublicIp,
+		name:     "multy_public_ip",
+		schema:   dataSourceSchema(publicIpSchema, "Provides an existing Multy Public IP"),
+	}
+}
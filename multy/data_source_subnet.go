@@ -0,0 +1,18 @@
+package multy
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+)
+
+type DataSourceSubnetType struct{}
+
+func (r DataSourceSubnetType) NewDataSource(_ context.Context, p provider.Provider) datasource.DataSource {
+	return MultyDataSource[Subnet]{
+		p:        *(p.(*Provider)),
+		readFunc: readSubnet,
+		name:     "multy_subnet",
+		schema:   dataSourceSchema(subnetSchema, "Provides an existing Multy Subnet"),
+	}
+}
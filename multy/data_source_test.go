@@ -0,0 +1,178 @@
+package multy
+
+import (
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"strings"
+	"testing"
+)
+
+func TestDataSourceLookup(t *testing.T) {
+	h := newPlanHarness(t, "aws")
+	h.apply("multy_virtual_network.vn", map[string]any{"name": "vn", "cidr_block": "10.0.0.0/16", "cloud": "aws", "location": "eu_west_1"})
+	h.apply("multy_subnet.a", map[string]any{"name": "subnet-a", "cidr_block": "10.0.1.0/24", "virtual_network_id": h.ids["multy_virtual_network.vn"]})
+	h.apply("multy_subnet.b", map[string]any{"name": "subnet-b", "cidr_block": "10.0.2.0/24", "virtual_network_id": h.ids["multy_virtual_network.vn"]})
+
+	tests := []struct {
+		name    string
+		config  map[string]any
+		err     error
+		want    string
+		wantErr string
+	}{
+		{
+			name:   "unique match",
+			config: map[string]any{"name": "subnet-b"},
+			want:   h.ids["multy_subnet.b"],
+		},
+		{
+			name:    "no match",
+			config:  map[string]any{"name": "subnet-c"},
+			wantErr: "No matching resource found",
+		},
+		{
+			name:    "multiple matches",
+			config:  map[string]any{"virtual_network_id": h.ids["multy_virtual_network.vn"]},
+			wantErr: "Multiple matching resources found",
+		},
+		{
+			name:    "read error",
+			config:  map[string]any{"name": "subnet-b"},
+			err:     status.Error(codes.PermissionDenied, "permission denied"),
+			wantErr: "Error reading resource",
+		},
+		{
+			name:    "invalid argument",
+			config:  map[string]any{"name": "subnet-b"},
+			err:     status.Error(codes.InvalidArgument, "invalid request"),
+			wantErr: "Error reading resource",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			h.fake.setError("ReadSubnet", tc.err)
+			defer h.fake.setError("ReadSubnet", nil)
+
+			id, errs := h.readDataSource("multy_subnet", tc.config)
+			if errs != tc.wantErr {
+				t.Fatalf("expected errors %q, got %q", tc.wantErr, errs)
+			}
+			if id != tc.want {
+				t.Errorf("expected to find %q, got %q", tc.want, id)
+			}
+		})
+	}
+}
+
+func TestDataSourceLookupSkipsKnownTypes(t *testing.T) {
+	h := newPlanHarness(t, "aws")
+	h.apply("multy_virtual_network.vn", map[string]any{"name": "vn", "cidr_block": "10.0.0.0/16", "cloud": "aws", "location": "eu_west_1"})
+	h.apply("multy_subnet.a", map[string]any{"name": "subnet-a", "cidr_block": "10.0.1.0/24", "virtual_network_id": h.ids["multy_virtual_network.vn"]})
+
+	before := len(h.fake.getCalls())
+	id, errs := h.readDataSource("multy_virtual_network", map[string]any{"name": "vn"})
+	if errs != "" {
+		t.Fatalf("unexpected errors %q", errs)
+	}
+	if id != h.ids["multy_virtual_network.vn"] {
+		t.Errorf("expected to find %q, got %q", h.ids["multy_virtual_network.vn"], id)
+	}
+	// the subnet was applied through the provider, so it isn't read while looking up a virtual network
+	var reads []string
+	for _, method := range h.fake.getCalls()[before:] {
+		if strings.HasPrefix(method, "Read") {
+			reads = append(reads, method)
+		}
+	}
+	if want := []string{"ReadVirtualNetwork"}; strings.Join(reads, ",") != strings.Join(want, ",") {
+		t.Errorf("expected reads %v, got %v", want, reads)
+	}
+}
+
+// readDataSource reads the data source with the given config and returns the id it found and its errors.
+func (h *planHarness) readDataSource(typeName string, config map[string]any) (string, string) {
+	schemaResp, err := h.server.GetProviderSchema(h.ctx, &tfprotov6.GetProviderSchemaRequest{})
+	h.check("get provider schema", err, schemaResp.Diagnostics)
+	schema := schemaResp.DataSourceSchemas[typeName]
+	configValue := h.dynamicValue(schema, config)
+
+	resp, err := h.server.ReadDataSource(h.ctx, &tfprotov6.ReadDataSourceRequest{TypeName: typeName, Config: &configValue})
+	if err != nil {
+		h.t.Fatalf("unable to read %s, %s", typeName, err)
+	}
+	if errs := diagnosticErrors(resp.Diagnostics); errs != "" {
+		var details []string
+		for _, d := range resp.Diagnostics {
+			details = append(details, d.Detail)
+		}
+		h.t.Logf("%s: %s", errs, strings.Join(details, "; "))
+		return "", errs
+	}
+	state, err := resp.State.Unmarshal(schema.ValueType())
+	if err != nil {
+		h.t.Fatalf("unable to decode state of %s, %s", typeName, err)
+	}
+	values := map[string]tftypes.Value{}
+	var id string
+	if err := state.As(&values); err != nil {
+		h.t.Fatalf("unable to decode state of %s, %s", typeName, err)
+	}
+	if err := values["id"].As(&id); err != nil {
+		h.t.Fatalf("unable to decode id of %s, %s", typeName, err)
+	}
+	return id, ""
+}
+
+func TestDataSourceAccount(t *testing.T) {
+	h := newPlanHarness(t, "aws")
+	h.apply("multy_virtual_network.vn", map[string]any{"name": "vn", "cidr_block": "10.0.0.0/16", "cloud": "aws", "location": "eu_west_1", "account": "other"})
+
+	tests := []struct {
+		name    string
+		config  map[string]any
+		wantKey string
+		wantErr string
+	}{
+		{
+			name:    "read by id",
+			config:  map[string]any{"id": h.ids["multy_virtual_network.vn"], "account": "other"},
+			wantKey: "other",
+		},
+		{
+			name:    "looked up",
+			config:  map[string]any{"name": "vn", "account": "other"},
+			wantKey: "other",
+		},
+		{
+			name:    "top-level credentials",
+			config:  map[string]any{"name": "vn"},
+			wantKey: "fake",
+		},
+		{
+			name:    "unknown account",
+			config:  map[string]any{"name": "vn", "account": "missing"},
+			wantErr: "Unknown account",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			id, errs := h.readDataSource("multy_virtual_network", tc.config)
+			if errs != tc.wantErr {
+				t.Fatalf("expected errors %q, got %q", tc.wantErr, errs)
+			}
+			if tc.wantErr != "" {
+				return
+			}
+			if id != h.ids["multy_virtual_network.vn"] {
+				t.Errorf("expected to find %q, got %q", h.ids["multy_virtual_network.vn"], id)
+			}
+			for _, method := range []string{"RefreshState", "ReadVirtualNetwork"} {
+				if got := h.fake.getCreds(method).GetAwsCreds().GetAccessKey(); got != tc.wantKey {
+					t.Errorf("%s: expected access key %q, got %q", method, tc.wantKey, got)
+				}
+			}
+		})
+	}
+}
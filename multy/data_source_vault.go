@@ -0,0 +1,18 @@
+package multy
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+)
+
+type DataSourceVaultType struct{}
+
+func (r DataSourceVaultType) NewDataSource(_ context.Context, p provider.Provider) datasource.DataSource {
+	return MultyDataSource[Vault]{
+		p:        *(p.(*Provider)),
+		readFunc: readVault,
+		name:     "multy_vault",
+		schema:   dataSourceSchema(vaultSchema, "Provides an existing Multy Vault"),
+	}
+}
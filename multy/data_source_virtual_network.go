@@ -0,0 +1,18 @@
+package multy
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+)
+
+type DataSourceVirtualNetworkType struct{}
+
+func (r DataSourceVirtualNetworkType) NewDataSource(_ context.Context, p provider.Provider) datasource.DataSource {
+	return MultyDataSource[VirtualNetwork]{
+		p:        *(p.(*Provider)),
+		readFunc: readVirtualNetwork,
+		name:     "multy_virtual_network",
+		schema:   dataSourceSchema(virtualNetworkSchema, "Provides an existing Multy Virtual Network"),
+	}
+}
@@ -0,0 +1,195 @@
+package multy
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"golang.org/x/exp/maps"
+	"math/big"
+	"sort"
+	"strings"
+)
+
+// attributeChange is an attribute whose value changed between two reads of a resource.
+type attributeChange struct {
+	path     *tftypes.AttributePath
+	old, new tftypes.Value
+}
+
+// driftWarning returns a warning listing the attributes of the resource that changed outside of terraform since it
+// was last read, so that changes such as rules edited in the cloud console are easy to spot. Attributes only computed
+// by the server, such as resource_status, are expected to change and aren't listed.
+func driftWarning(ctx context.Context, schema tfsdk.Schema, resourceName string, prior, current tftypes.Value) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if prior.IsNull() || current.IsNull() {
+		return diags
+	}
+	var changes []attributeChange
+	for _, change := range changedAttributeValues(tftypes.NewAttributePath(), prior, current) {
+		if !isComputedOnly(ctx, schema, change.path) {
+			changes = append(changes, change)
+		}
+	}
+	if len(changes) == 0 {
+		return diags
+	}
+
+	var lines []string
+	for _, change := range changes {
+		old, new := formatValue(change.old), formatValue(change.new)
+		if isSensitive(ctx, schema, change.path) {
+			old, new = "(sensitive value)", "(sensitive value)"
+		}
+		lines = append(lines, fmt.Sprintf("  - %s: %s → %s", formatPath(change.path), old, new))
+	}
+	diags.AddWarning(
+		"Resource changed outside of Terraform",
+		fmt.Sprintf("The following attributes of %s %s changed since it was last read:\n%s", resourceName, getString(current, "id"), strings.Join(lines, "\n")),
+	)
+	return diags
+}
+
+// changedAttributeValues returns the attributes whose value differs between prior and current. Objects, maps and lists
+// of the same length are compared element by element, so that only the values that changed are returned.
+func changedAttributeValues(p *tftypes.AttributePath, prior, current tftypes.Value) []attributeChange {
+	if prior.Equal(current) {
+		return nil
+	}
+	if prior.IsNull() || current.IsNull() || !prior.IsKnown() || !current.IsKnown() {
+		return []attributeChange{{path: p, old: prior, new: current}}
+	}
+
+	var changes []attributeChange
+	switch prior.Type().(type) {
+	case tftypes.Object, tftypes.Map:
+		_, isMap := prior.Type().(tftypes.Map)
+		priorValues, currentValues := map[string]tftypes.Value{}, map[string]tftypes.Value{}
+		_ = prior.As(&priorValues)
+		_ = current.As(&currentValues)
+		keys := maps.Keys(priorValues)
+		for k := range currentValues {
+			if _, ok := priorValues[k]; !ok {
+				keys = append(keys, k)
+			}
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			old, ok := priorValues[k]
+			if !ok {
+				old = tftypes.NewValue(currentValues[k].Type(), nil)
+			}
+			new, ok := currentValues[k]
+			if !ok {
+				new = tftypes.NewValue(old.Type(), nil)
+			}
+			elemPath := p.WithAttributeName(k)
+			if isMap {
+				elemPath = p.WithElementKeyString(k)
+			}
+			changes = append(changes, changedAttributeValues(elemPath, old, new)...)
+		}
+	case tftypes.List:
+		var priorElems, currentElems []tftypes.Value
+		_ = prior.As(&priorElems)
+		_ = current.As(&currentElems)
+		if len(priorElems) != len(currentElems) {
+			return []attributeChange{{path: p, old: prior, new: current}}
+		}
+		for i := range priorElems {
+			changes = append(changes, changedAttributeValues(p.WithElementKeyInt(i), priorElems[i], currentElems[i])...)
+		}
+	default:
+		return []attributeChange{{path: p, old: prior, new: current}}
+	}
+	return changes
+}
+
+// isSensitive checks whether the attribute at p, or any attribute containing it, is sensitive.
+func isSensitive(ctx context.Context, schema tfsdk.Schema, p *tftypes.AttributePath) bool {
+	for ; len(p.Steps()) > 0; p = p.WithoutLastStep() {
+		if a, err := schema.AttributeAtTerraformPath(ctx, p); err == nil && a.IsSensitive() {
+			return true
+		}
+	}
+	return false
+}
+
+// isComputedOnly checks whether the attribute at p, or any attribute containing it, can't be set in the config.
+func isComputedOnly(ctx context.Context, schema tfsdk.Schema, p *tftypes.AttributePath) bool {
+	for ; len(p.Steps()) > 0; p = p.WithoutLastStep() {
+		if a, err := schema.AttributeAtTerraformPath(ctx, p); err == nil && a.IsComputed() && !a.IsOptional() && !a.IsRequired() {
+			return true
+		}
+	}
+	return false
+}
+
+func formatPath(p *tftypes.AttributePath) string {
+	var b strings.Builder
+	for _, step := range p.Steps() {
+		switch s := step.(type) {
+		case tftypes.AttributeName:
+			if b.Len() > 0 {
+				b.WriteString(".")
+			}
+			b.WriteString(string(s))
+		case tftypes.ElementKeyInt:
+			fmt.Fprintf(&b, "[%d]", s)
+		case tftypes.ElementKeyString:
+			fmt.Fprintf(&b, "[%q]", string(s))
+		default:
+			b.WriteString("[*]")
+		}
+	}
+	return b.String()
+}
+
+// formatValue formats v the way it would be written in a terraform config.
+func formatValue(v tftypes.Value) string {
+	if v.IsNull() {
+		return "null"
+	}
+	if !v.IsKnown() {
+		return "(known after apply)"
+	}
+	switch t := v.Type().(type) {
+	case tftypes.Object, tftypes.Map:
+		values := map[string]tftypes.Value{}
+		_ = v.As(&values)
+		keys := maps.Keys(values)
+		sort.Strings(keys)
+		var elems []string
+		for _, k := range keys {
+			if _, isObject := t.(tftypes.Object); isObject && values[k].IsNull() {
+				continue
+			}
+			elems = append(elems, fmt.Sprintf("%s = %s", k, formatValue(values[k])))
+		}
+		return "{" + strings.Join(elems, ", ") + "}"
+	case tftypes.List, tftypes.Set, tftypes.Tuple:
+		var values []tftypes.Value
+		_ = v.As(&values)
+		var elems []string
+		for _, e := range values {
+			elems = append(elems, formatValue(e))
+		}
+		return "[" + strings.Join(elems, ", ") + "]"
+	}
+	switch {
+	case v.Type().Is(tftypes.String):
+		var s string
+		_ = v.As(&s)
+		return fmt.Sprintf("%q", s)
+	case v.Type().Is(tftypes.Number):
+		n := new(big.Float)
+		_ = v.As(&n)
+		return n.Text('f', -1)
+	case v.Type().Is(tftypes.Bool):
+		var b bool
+		_ = v.As(&b)
+		return fmt.Sprint(b)
+	}
+	return v.String()
+}
@@ -0,0 +1,93 @@
+package multy
+
+import (
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/multycloud/multy/api/proto/commonpb"
+	"github.com/multycloud/multy/api/proto/resourcespb"
+	"strings"
+	"testing"
+)
+
+func TestReadDriftWarning(t *testing.T) {
+	h := newPlanHarness(t, "aws")
+	h.apply("multy_virtual_network.vn", map[string]any{"name": "vn", "cidr_block": "10.0.0.0/16", "cloud": "aws", "location": "eu_west_1"})
+	h.apply("multy_subnet.subnet", map[string]any{"name": "subnet", "cidr_block": "10.0.1.0/24", "virtual_network_id": h.ids["multy_virtual_network.vn"]})
+	h.apply("multy_network_security_group.nsg", map[string]any{
+		"name":               "nsg",
+		"virtual_network_id": h.ids["multy_virtual_network.vn"],
+		"cloud":              "aws",
+		"location":           "eu_west_1",
+		"rule":               []any{map[string]any{"protocol": "tcp", "priority": 120, "from_port": 22, "to_port": 22, "cidr_block": "0.0.0.0/0", "direction": "both"}},
+	})
+	h.apply("multy_database.db", map[string]any{
+		"name":           "db",
+		"engine":         "mysql",
+		"engine_version": "5.7",
+		"username":       "admin",
+		"password":       "password-1",
+		"size":           "micro",
+		"storage_gb":     10,
+		"subnet_id":      h.ids["multy_subnet.subnet"],
+		"cloud":          "aws",
+		"location":       "eu_west_1",
+	})
+
+	if diags := h.read("multy_network_security_group.nsg"); len(diags) > 0 {
+		t.Fatalf("unexpected diagnostics reading an unchanged resource: %s", diags[0].Summary)
+	}
+
+	h.fake.mu.Lock()
+	nsg := h.fake.resources[h.ids["multy_network_security_group.nsg"]].(*resourcespb.NetworkSecurityGroupResource)
+	nsg.Rules[0].PortRange.From = 0
+	nsg.Rules[0].CidrBlock = "10.0.0.0/8"
+	nsg.CommonParameters.ResourceStatus = needsUpdate("aws_security_group")
+	db := h.fake.resources[h.ids["multy_database.db"]].(*resourcespb.DatabaseResource)
+	db.Password = "password-2"
+	h.fake.mu.Unlock()
+
+	detail := driftDetail(t, h.read("multy_network_security_group.nsg"))
+	for _, want := range []string{
+		"multy_network_security_group " + h.ids["multy_network_security_group.nsg"],
+		`rule[0].cidr_block: "0.0.0.0/0" → "10.0.0.0/8"`,
+		"rule[0].from_port: 22 → 0",
+	} {
+		if !strings.Contains(detail, want) {
+			t.Errorf("warning doesn't contain %q:\n%s", want, detail)
+		}
+	}
+	if strings.Contains(detail, "to_port") || strings.Contains(detail, "name") || strings.Contains(detail, "resource_status") {
+		t.Errorf("warning contains attributes that didn't change:\n%s", detail)
+	}
+
+	detail = driftDetail(t, h.read("multy_database.db"))
+	if !strings.Contains(detail, "password: (sensitive value) → (sensitive value)") || strings.Contains(detail, "password-") {
+		t.Errorf("warning doesn't hide the sensitive value:\n%s", detail)
+	}
+
+	if diags := h.read("multy_network_security_group.nsg"); len(diags) > 0 {
+		t.Errorf("unexpected diagnostics reading the resource again: %s", diags[0].Summary)
+	}
+
+	// resource_status is only computed by the server, so it changing isn't drift
+	h.fake.mu.Lock()
+	nsg.CommonParameters.ResourceStatus = needsUpdate("aws_security_group", "aws_security_group_rule")
+	h.fake.mu.Unlock()
+	if diags := h.read("multy_network_security_group.nsg"); len(diags) > 0 {
+		t.Errorf("unexpected diagnostics after resource_status changed: %s\n%s", diags[0].Summary, diags[0].Detail)
+	}
+}
+
+func needsUpdate(resources ...string) *commonpb.ResourceStatus {
+	statuses := map[string]commonpb.ResourceStatus_Status{}
+	for _, r := range resources {
+		statuses[r] = commonpb.ResourceStatus_NEEDS_UPDATE
+	}
+	return &commonpb.ResourceStatus{Statuses: statuses}
+}
+
+func driftDetail(t *testing.T, diags []*tfprotov6.Diagnostic) string {
+	if len(diags) != 1 || diags[0].Severity != tfprotov6.DiagnosticSeverityWarning {
+		t.Fatalf("expected a single drift warning, got %v", diags)
+	}
+	return diags[0].Detail
+}
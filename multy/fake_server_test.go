@@ -0,0 +1,581 @@
+package multy
+
+import (
+	"context"
+	"fmt"
+	"github.com/multycloud/multy/api/proto"
+	"github.com/multycloud/multy/api/proto/commonpb"
+	"github.com/multycloud/multy/api/proto/credspb"
+	"github.com/multycloud/multy/api/proto/errorspb"
+	"github.com/multycloud/multy/api/proto/resourcespb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	protobuf "google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"net"
+	"path"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// fakeServer is an in-memory multy server, so that the provider can be tested without a real one. Resources are
+// returned as they were created, with a generated id, and references to other resources must exist.
+type fakeServer struct {
+	proto.UnimplementedMultyResourceServiceServer
+
+	mu        sync.Mutex
+	resources map[string]protobuf.Message
+	lastId    int
+	// errors are returned instead of handling calls to the method with the same name, such as CreateVirtualNetwork
+	errors map[string]error
+	// calls are the names of the methods that were called, in order
+	calls []string
+	// creds are the cloud credentials sent in the last call to each method
+	creds map[string]*credspb.CloudCredentials
+
+	listener *bufconn.Listener
+}
+
+// newFakeServer starts a fake server that is stopped when the test finishes. Providers connect to it through
+// fakeServer.dial.
+func newFakeServer(t *testing.T) *fakeServer {
+	s := &fakeServer{
+		resources: map[string]protobuf.Message{},
+		errors:    map[string]error{},
+		creds:     map[string]*credspb.CloudCredentials{},
+		listener:  bufconn.Listen(1024 * 1024),
+	}
+	server := grpc.NewServer(grpc.UnaryInterceptor(s.intercept))
+	proto.RegisterMultyResourceServiceServer(server, s)
+	go func() {
+		_ = server.Serve(s.listener)
+	}()
+	t.Cleanup(server.Stop)
+	return s
+}
+
+func (s *fakeServer) dial(ctx context.Context, _ string) (net.Conn, error) {
+	return s.listener.DialContext(ctx)
+}
+
+// setError makes every call to the given method fail with err, until it's set to nil.
+func (s *fakeServer) setError(method string, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if err == nil {
+		delete(s.errors, method)
+	} else {
+		s.errors[method] = err
+	}
+}
+
+// getCalls returns the names of the methods that were called so far.
+func (s *fakeServer) getCalls() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]string(nil), s.calls...)
+}
+
+// getCreds returns the cloud credentials sent in the last call to the given method.
+func (s *fakeServer) getCreds(method string) *credspb.CloudCredentials {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.creds[method]
+}
+
+func (s *fakeServer) intercept(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := path.Base(info.FullMethod)
+	md, _ := metadata.FromIncomingContext(ctx)
+	creds := &credspb.CloudCredentials{}
+	if values := md.Get("cloud-creds-bin"); len(values) > 0 {
+		if err := protobuf.Unmarshal([]byte(values[0]), creds); err != nil {
+			return nil, status.Error(codes.InvalidArgument, "invalid cloud credentials")
+		}
+	}
+	s.mu.Lock()
+	s.calls = append(s.calls, method)
+	s.creds[method] = creds
+	err := s.errors[method]
+	s.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if len(md.Get("api_key")) == 0 {
+		return nil, status.Error(codes.Unauthenticated, "api key not set")
+	}
+	return handler(ctx, req)
+}
+
+func (s *fakeServer) RefreshState(context.Context, *proto.RefreshStateRequest) (*proto.RefreshStateResponse, error) {
+	return &proto.RefreshStateResponse{}, nil
+}
+
+func (s *fakeServer) ListResources(context.Context, *proto.ListResourcesRequest) (*proto.ListResourcesResponse, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id := range s.resources {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return &proto.ListResourcesResponse{Resources: ids}, nil
+}
+
+func (s *fakeServer) CreateVirtualNetwork(_ context.Context, req *resourcespb.CreateVirtualNetworkRequest) (*resourcespb.VirtualNetworkResource, error) {
+	return create[*resourcespb.VirtualNetworkResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadVirtualNetwork(_ context.Context, req *resourcespb.ReadVirtualNetworkRequest) (*resourcespb.VirtualNetworkResource, error) {
+	return read[*resourcespb.VirtualNetworkResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateVirtualNetwork(_ context.Context, req *resourcespb.UpdateVirtualNetworkRequest) (*resourcespb.VirtualNetworkResource, error) {
+	return update[*resourcespb.VirtualNetworkResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteVirtualNetwork(_ context.Context, req *resourcespb.DeleteVirtualNetworkRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateSubnet(_ context.Context, req *resourcespb.CreateSubnetRequest) (*resourcespb.SubnetResource, error) {
+	return create[*resourcespb.SubnetResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadSubnet(_ context.Context, req *resourcespb.ReadSubnetRequest) (*resourcespb.SubnetResource, error) {
+	return read[*resourcespb.SubnetResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateSubnet(_ context.Context, req *resourcespb.UpdateSubnetRequest) (*resourcespb.SubnetResource, error) {
+	return update[*resourcespb.SubnetResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteSubnet(_ context.Context, req *resourcespb.DeleteSubnetRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateNetworkSecurityGroup(_ context.Context, req *resourcespb.CreateNetworkSecurityGroupRequest) (*resourcespb.NetworkSecurityGroupResource, error) {
+	return create[*resourcespb.NetworkSecurityGroupResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadNetworkSecurityGroup(_ context.Context, req *resourcespb.ReadNetworkSecurityGroupRequest) (*resourcespb.NetworkSecurityGroupResource, error) {
+	return read[*resourcespb.NetworkSecurityGroupResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateNetworkSecurityGroup(_ context.Context, req *resourcespb.UpdateNetworkSecurityGroupRequest) (*resourcespb.NetworkSecurityGroupResource, error) {
+	return update[*resourcespb.NetworkSecurityGroupResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteNetworkSecurityGroup(_ context.Context, req *resourcespb.DeleteNetworkSecurityGroupRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateDatabase(_ context.Context, req *resourcespb.CreateDatabaseRequest) (*resourcespb.DatabaseResource, error) {
+	return create[*resourcespb.DatabaseResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadDatabase(_ context.Context, req *resourcespb.ReadDatabaseRequest) (*resourcespb.DatabaseResource, error) {
+	return read[*resourcespb.DatabaseResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateDatabase(_ context.Context, req *resourcespb.UpdateDatabaseRequest) (*resourcespb.DatabaseResource, error) {
+	return update[*resourcespb.DatabaseResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteDatabase(_ context.Context, req *resourcespb.DeleteDatabaseRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateKubernetesNodePool(_ context.Context, req *resourcespb.CreateKubernetesNodePoolRequest) (*resourcespb.KubernetesNodePoolResource, error) {
+	return create[*resourcespb.KubernetesNodePoolResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadKubernetesNodePool(_ context.Context, req *resourcespb.ReadKubernetesNodePoolRequest) (*resourcespb.KubernetesNodePoolResource, error) {
+	return read[*resourcespb.KubernetesNodePoolResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateKubernetesNodePool(_ context.Context, req *resourcespb.UpdateKubernetesNodePoolRequest) (*resourcespb.KubernetesNodePoolResource, error) {
+	return update[*resourcespb.KubernetesNodePoolResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteKubernetesNodePool(_ context.Context, req *resourcespb.DeleteKubernetesNodePoolRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateKubernetesCluster(_ context.Context, req *resourcespb.CreateKubernetesClusterRequest) (*resourcespb.KubernetesClusterResource, error) {
+	return create[*resourcespb.KubernetesClusterResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadKubernetesCluster(_ context.Context, req *resourcespb.ReadKubernetesClusterRequest) (*resourcespb.KubernetesClusterResource, error) {
+	return read[*resourcespb.KubernetesClusterResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateKubernetesCluster(_ context.Context, req *resourcespb.UpdateKubernetesClusterRequest) (*resourcespb.KubernetesClusterResource, error) {
+	return update[*resourcespb.KubernetesClusterResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteKubernetesCluster(_ context.Context, req *resourcespb.DeleteKubernetesClusterRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateNetworkInterface(_ context.Context, req *resourcespb.CreateNetworkInterfaceRequest) (*resourcespb.NetworkInterfaceResource, error) {
+	return create[*resourcespb.NetworkInterfaceResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadNetworkInterface(_ context.Context, req *resourcespb.ReadNetworkInterfaceRequest) (*resourcespb.NetworkInterfaceResource, error) {
+	return read[*resourcespb.NetworkInterfaceResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateNetworkInterface(_ context.Context, req *resourcespb.UpdateNetworkInterfaceRequest) (*resourcespb.NetworkInterfaceResource, error) {
+	return update[*resourcespb.NetworkInterfaceResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteNetworkInterface(_ context.Context, req *resourcespb.DeleteNetworkInterfaceRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateNetworkInterfaceSecurityGroupAssociation(_ context.Context, req *resourcespb.CreateNetworkInterfaceSecurityGroupAssociationRequest) (*resourcespb.NetworkInterfaceSecurityGroupAssociationResource, error) {
+	return create[*resourcespb.NetworkInterfaceSecurityGroupAssociationResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadNetworkInterfaceSecurityGroupAssociation(_ context.Context, req *resourcespb.ReadNetworkInterfaceSecurityGroupAssociationRequest) (*resourcespb.NetworkInterfaceSecurityGroupAssociationResource, error) {
+	return read[*resourcespb.NetworkInterfaceSecurityGroupAssociationResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateNetworkInterfaceSecurityGroupAssociation(_ context.Context, req *resourcespb.UpdateNetworkInterfaceSecurityGroupAssociationRequest) (*resourcespb.NetworkInterfaceSecurityGroupAssociationResource, error) {
+	return update[*resourcespb.NetworkInterfaceSecurityGroupAssociationResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteNetworkInterfaceSecurityGroupAssociation(_ context.Context, req *resourcespb.DeleteNetworkInterfaceSecurityGroupAssociationRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateObjectStorage(_ context.Context, req *resourcespb.CreateObjectStorageRequest) (*resourcespb.ObjectStorageResource, error) {
+	return create[*resourcespb.ObjectStorageResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadObjectStorage(_ context.Context, req *resourcespb.ReadObjectStorageRequest) (*resourcespb.ObjectStorageResource, error) {
+	return read[*resourcespb.ObjectStorageResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateObjectStorage(_ context.Context, req *resourcespb.UpdateObjectStorageRequest) (*resourcespb.ObjectStorageResource, error) {
+	return update[*resourcespb.ObjectStorageResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteObjectStorage(_ context.Context, req *resourcespb.DeleteObjectStorageRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateObjectStorageObject(_ context.Context, req *resourcespb.CreateObjectStorageObjectRequest) (*resourcespb.ObjectStorageObjectResource, error) {
+	return create[*resourcespb.ObjectStorageObjectResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadObjectStorageObject(_ context.Context, req *resourcespb.ReadObjectStorageObjectRequest) (*resourcespb.ObjectStorageObjectResource, error) {
+	return read[*resourcespb.ObjectStorageObjectResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateObjectStorageObject(_ context.Context, req *resourcespb.UpdateObjectStorageObjectRequest) (*resourcespb.ObjectStorageObjectResource, error) {
+	return update[*resourcespb.ObjectStorageObjectResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteObjectStorageObject(_ context.Context, req *resourcespb.DeleteObjectStorageObjectRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreatePublicIp(_ context.Context, req *resourcespb.CreatePublicIpRequest) (*resourcespb.PublicIpResource, error) {
+	return create[*resourcespb.PublicIpResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadPublicIp(_ context.Context, req *resourcespb.ReadPublicIpRequest) (*resourcespb.PublicIpResource, error) {
+	return read[*resourcespb.PublicIpResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdatePublicIp(_ context.Context, req *resourcespb.UpdatePublicIpRequest) (*resourcespb.PublicIpResource, error) {
+	return update[*resourcespb.PublicIpResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeletePublicIp(_ context.Context, req *resourcespb.DeletePublicIpRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateRouteTable(_ context.Context, req *resourcespb.CreateRouteTableRequest) (*resourcespb.RouteTableResource, error) {
+	return create[*resourcespb.RouteTableResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadRouteTable(_ context.Context, req *resourcespb.ReadRouteTableRequest) (*resourcespb.RouteTableResource, error) {
+	return read[*resourcespb.RouteTableResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateRouteTable(_ context.Context, req *resourcespb.UpdateRouteTableRequest) (*resourcespb.RouteTableResource, error) {
+	return update[*resourcespb.RouteTableResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteRouteTable(_ context.Context, req *resourcespb.DeleteRouteTableRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateRouteTableAssociation(_ context.Context, req *resourcespb.CreateRouteTableAssociationRequest) (*resourcespb.RouteTableAssociationResource, error) {
+	return create[*resourcespb.RouteTableAssociationResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadRouteTableAssociation(_ context.Context, req *resourcespb.ReadRouteTableAssociationRequest) (*resourcespb.RouteTableAssociationResource, error) {
+	return read[*resourcespb.RouteTableAssociationResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateRouteTableAssociation(_ context.Context, req *resourcespb.UpdateRouteTableAssociationRequest) (*resourcespb.RouteTableAssociationResource, error) {
+	return update[*resourcespb.RouteTableAssociationResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteRouteTableAssociation(_ context.Context, req *resourcespb.DeleteRouteTableAssociationRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateVault(_ context.Context, req *resourcespb.CreateVaultRequest) (*resourcespb.VaultResource, error) {
+	return create[*resourcespb.VaultResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadVault(_ context.Context, req *resourcespb.ReadVaultRequest) (*resourcespb.VaultResource, error) {
+	return read[*resourcespb.VaultResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateVault(_ context.Context, req *resourcespb.UpdateVaultRequest) (*resourcespb.VaultResource, error) {
+	return update[*resourcespb.VaultResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteVault(_ context.Context, req *resourcespb.DeleteVaultRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateVaultAccessPolicy(_ context.Context, req *resourcespb.CreateVaultAccessPolicyRequest) (*resourcespb.VaultAccessPolicyResource, error) {
+	return create[*resourcespb.VaultAccessPolicyResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadVaultAccessPolicy(_ context.Context, req *resourcespb.ReadVaultAccessPolicyRequest) (*resourcespb.VaultAccessPolicyResource, error) {
+	return read[*resourcespb.VaultAccessPolicyResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateVaultAccessPolicy(_ context.Context, req *resourcespb.UpdateVaultAccessPolicyRequest) (*resourcespb.VaultAccessPolicyResource, error) {
+	return update[*resourcespb.VaultAccessPolicyResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteVaultAccessPolicy(_ context.Context, req *resourcespb.DeleteVaultAccessPolicyRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateVaultSecret(_ context.Context, req *resourcespb.CreateVaultSecretRequest) (*resourcespb.VaultSecretResource, error) {
+	return create[*resourcespb.VaultSecretResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadVaultSecret(_ context.Context, req *resourcespb.ReadVaultSecretRequest) (*resourcespb.VaultSecretResource, error) {
+	return read[*resourcespb.VaultSecretResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateVaultSecret(_ context.Context, req *resourcespb.UpdateVaultSecretRequest) (*resourcespb.VaultSecretResource, error) {
+	return update[*resourcespb.VaultSecretResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteVaultSecret(_ context.Context, req *resourcespb.DeleteVaultSecretRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+func (s *fakeServer) CreateVirtualMachine(_ context.Context, req *resourcespb.CreateVirtualMachineRequest) (*resourcespb.VirtualMachineResource, error) {
+	return create[*resourcespb.VirtualMachineResource](s, req.Resource)
+}
+
+func (s *fakeServer) ReadVirtualMachine(_ context.Context, req *resourcespb.ReadVirtualMachineRequest) (*resourcespb.VirtualMachineResource, error) {
+	return read[*resourcespb.VirtualMachineResource](s, req.ResourceId)
+}
+
+func (s *fakeServer) UpdateVirtualMachine(_ context.Context, req *resourcespb.UpdateVirtualMachineRequest) (*resourcespb.VirtualMachineResource, error) {
+	return update[*resourcespb.VirtualMachineResource](s, req.ResourceId, req.Resource)
+}
+
+func (s *fakeServer) DeleteVirtualMachine(_ context.Context, req *resourcespb.DeleteVirtualMachineRequest) (*commonpb.Empty, error) {
+	return s.delete(req.ResourceId)
+}
+
+var resourceKindRegex = regexp.MustCompile(`([a-z])([A-Z])`)
+
+// create stores a new resource of type T with the values in args, under an id such as multy-virtual-network-1.
+func create[T protobuf.Message](s *fakeServer, args protobuf.Message) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result T
+	if err := s.checkReferences(args); err != nil {
+		return result, err
+	}
+
+	result = newMessage[T]()
+	copyFields(result.ProtoReflect(), protobuf.Clone(args).ProtoReflect())
+	s.lastId++
+	kind := strings.TrimSuffix(string(result.ProtoReflect().Descriptor().Name()), "Resource")
+	kind = strings.ToLower(resourceKindRegex.ReplaceAllString(kind, "$1-$2"))
+	id := fmt.Sprintf("multy-%s-%d", kind, s.lastId)
+	setCommonParameters(result, id, fmt.Sprintf("multy-rg-%d", s.lastId))
+
+	s.resources[id] = result
+	return protobuf.Clone(result).(T), nil
+}
+
+func read[T protobuf.Message](s *fakeServer, id string) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result T
+	stored, err := s.get(id, newMessage[T]())
+	if err != nil {
+		return result, err
+	}
+	return protobuf.Clone(stored).(T), nil
+}
+
+// update replaces the values of the resource with the ones in args, keeping its id and resource group.
+func update[T protobuf.Message](s *fakeServer, id string, args protobuf.Message) (T, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var result T
+	stored, err := s.get(id, newMessage[T]())
+	if err != nil {
+		return result, err
+	}
+	if err := s.checkReferences(args); err != nil {
+		return result, err
+	}
+
+	result = newMessage[T]()
+	copyFields(result.ProtoReflect(), protobuf.Clone(args).ProtoReflect())
+	common := stored.ProtoReflect().Get(stored.ProtoReflect().Descriptor().Fields().ByName("common_parameters")).Message()
+	resourceGroupId := ""
+	if fd := common.Descriptor().Fields().ByName("resource_group_id"); fd != nil {
+		resourceGroupId = common.Get(fd).String()
+	}
+	setCommonParameters(result, id, resourceGroupId)
+
+	s.resources[id] = result
+	return protobuf.Clone(result).(T), nil
+}
+
+func (s *fakeServer) delete(id string) (*commonpb.Empty, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.resources[id]; !ok {
+		return nil, status.Errorf(codes.NotFound, "resource %s not found", id)
+	}
+	delete(s.resources, id)
+	return &commonpb.Empty{}, nil
+}
+
+// get returns the resource with the given id, if it has the same type as want.
+func (s *fakeServer) get(id string, want protobuf.Message) (protobuf.Message, error) {
+	stored, ok := s.resources[id]
+	if !ok || stored.ProtoReflect().Descriptor() != want.ProtoReflect().Descriptor() {
+		return nil, status.Errorf(codes.NotFound, "resource %s not found", id)
+	}
+	return stored, nil
+}
+
+// checkReferences returns an InvalidArgument error if any of the ids that args refers to, in fields such as subnet_id
+// or network_interface_ids, doesn't exist.
+func (s *fakeServer) checkReferences(args protobuf.Message) error {
+	var details []*errorspb.ResourceValidationError
+	args.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if fd.Kind() != protoreflect.StringKind || fd.IsMap() {
+			return true
+		}
+		var ids []string
+		if fd.IsList() && strings.HasSuffix(string(fd.Name()), "_ids") {
+			for i := 0; i < v.List().Len(); i++ {
+				ids = append(ids, v.List().Get(i).String())
+			}
+		} else if !fd.IsList() && strings.HasSuffix(string(fd.Name()), "_id") {
+			ids = append(ids, v.String())
+		}
+		for _, id := range ids {
+			if _, ok := s.resources[id]; !ok {
+				details = append(details, &errorspb.ResourceValidationError{
+					ErrorMessage: fmt.Sprintf("resource %s doesn't exist", id),
+					FieldName:    string(fd.Name()),
+				})
+			}
+		}
+		return true
+	})
+	if len(details) == 0 {
+		return nil
+	}
+	st := status.New(codes.InvalidArgument, "validation errors found")
+	for _, d := range details {
+		st, _ = st.WithDetails(d)
+	}
+	return st.Err()
+}
+
+func newMessage[T protobuf.Message]() T {
+	var zero T
+	return zero.ProtoReflect().Type().New().Interface().(T)
+}
+
+// copyFields sets every field in dst to the value of the field with the same name in src. Messages of different types,
+// such as the common parameters of args and resources, are copied field by field.
+func copyFields(dst, src protoreflect.Message) {
+	src.Range(func(srcFd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		dstFd := dst.Descriptor().Fields().ByName(srcFd.Name())
+		if dstFd == nil || dstFd.Kind() != srcFd.Kind() || dstFd.Cardinality() != srcFd.Cardinality() || dstFd.IsMap() != srcFd.IsMap() {
+			return true
+		}
+		if srcFd.Message() != nil && !srcFd.IsList() && !srcFd.IsMap() && dstFd.Message().FullName() != srcFd.Message().FullName() {
+			copyFields(dst.Mutable(dstFd).Message(), v.Message())
+			return true
+		}
+		if srcFd.IsMap() {
+			if !sameType(dstFd.MapKey(), srcFd.MapKey()) || !sameType(dstFd.MapValue(), srcFd.MapValue()) {
+				return true
+			}
+		} else if !sameType(dstFd, srcFd) {
+			return true
+		}
+		dst.Set(dstFd, v)
+		return true
+	})
+}
+
+// sameType checks whether values of src can be set on dst.
+func sameType(dst, src protoreflect.FieldDescriptor) bool {
+	switch {
+	case dst.Kind() != src.Kind():
+		return false
+	case src.Message() != nil:
+		return dst.Message().FullName() == src.Message().FullName()
+	case src.Enum() != nil:
+		return dst.Enum().FullName() == src.Enum().FullName()
+	}
+	return true
+}
+
+// setCommonParameters sets the resource id of the common parameters of resource, and its resource group id if it
+// has one and it wasn't set explicitly. Nested resources, such as the default node pool of a cluster, get an id based
+// on the id of resource.
+func setCommonParameters(resource protobuf.Message, id string, resourceGroupId string) {
+	setCommonParametersOf(resource.ProtoReflect(), id, resourceGroupId)
+}
+
+func setCommonParametersOf(m protoreflect.Message, id string, resourceGroupId string) {
+	fields := m.Descriptor().Fields()
+	common := m.Mutable(fields.ByName("common_parameters")).Message()
+	common.Set(common.Descriptor().Fields().ByName("resource_id"), protoreflect.ValueOfString(id))
+	if fd := common.Descriptor().Fields().ByName("resource_group_id"); fd != nil && common.Get(fd).String() == "" {
+		common.Set(fd, protoreflect.ValueOfString(resourceGroupId))
+	}
+	for i := 0; i < fields.Len(); i++ {
+		fd := fields.Get(i)
+		if fd.Message() == nil || fd.IsList() || fd.IsMap() || !m.Has(fd) || fd.Message().Fields().ByName("common_parameters") == nil {
+			continue
+		}
+		nestedId := fmt.Sprintf("%s-%s", id, strings.ReplaceAll(string(fd.Name()), "_", "-"))
+		setCommonParametersOf(m.Mutable(fd).Message(), nestedId, resourceGroupId)
+	}
+}
@@ -0,0 +1,89 @@
+package multy
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/resource"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"reflect"
+	"strings"
+	"terraform-provider-multy/multy/common"
+	"testing"
+)
+
+// TestFieldNames checks that every field of the server API can be mapped to an attribute, so that validation errors
+// from the server are attached to it.
+func TestFieldNames(t *testing.T) {
+	p := &Provider{}
+	for _, newResource := range p.Resources(context.Background()) {
+		r := newResource()
+		metadata := &resource.MetadataResponse{}
+		r.Metadata(context.Background(), resource.MetadataRequest{}, metadata)
+		t.Run(metadata.TypeName, func(t *testing.T) {
+			schema, _ := r.GetSchema(context.Background())
+			fieldNames := map[string]string{}
+			// MultyResource is generic, so its field names are read through reflection
+			iter := reflect.ValueOf(r).FieldByName("fieldNames").MapRange()
+			for iter.Next() {
+				fieldNames[iter.Key().String()] = iter.Value().String()
+			}
+
+			argsName := protoreflect.FullName("dev.multy.resources." + toCamel(strings.TrimPrefix(metadata.TypeName, "multy_")) + "Args")
+			args, err := protoregistry.GlobalFiles.FindDescriptorByName(argsName)
+			if err != nil {
+				t.Fatalf("unable to find %s, %s", argsName, err)
+			}
+			for _, field := range protoFields(args.(protoreflect.MessageDescriptor), "") {
+				name, ok := fieldNames[field]
+				if !ok {
+					name = field[strings.LastIndex(field, ".")+1:]
+				} else if name == "" {
+					// flattened in the schema
+					continue
+				}
+				p, ok := common.FieldPath(schema, fieldNames, field)
+				if !ok || !strings.HasSuffix(p.String(), name) || strings.Count(p.String(), ".") != strings.Count(field, ".")-flattened(fieldNames, field) {
+					t.Errorf("field %s doesn't match an attribute, got %s", field, p)
+				}
+			}
+		})
+	}
+}
+
+// flattened returns how many of the parents of field are flattened in the schema.
+func flattened(fieldNames map[string]string, field string) int {
+	count := 0
+	segments := strings.Split(field, ".")
+	for i := 1; i < len(segments); i++ {
+		if name, ok := fieldNames[strings.Join(segments[:i], ".")]; ok && name == "" {
+			count++
+		}
+	}
+	return count
+}
+
+// protoFields returns the dot-separated names of every field in message and its nested messages.
+func protoFields(message protoreflect.MessageDescriptor, prefix string) []string {
+	var result []string
+	fields := message.Fields()
+	for i := 0; i < fields.Len(); i++ {
+		f := fields.Get(i)
+		if f.Name() == "common_parameters" {
+			continue
+		}
+		name := prefix + string(f.Name())
+		result = append(result, name)
+		if f.Message() != nil && !f.IsMap() {
+			result = append(result, protoFields(f.Message(), name+".")...)
+		}
+	}
+	return result
+}
+
+func toCamel(s string) string {
+	var result strings.Builder
+	for _, word := range strings.Split(s, "_") {
+		result.WriteString(strings.ToUpper(word[:1]) + word[1:])
+	}
+	return result.String()
+}
@@ -0,0 +1,105 @@
+package multy
+
+import (
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"github.com/multycloud/multy/api/proto/commonpb"
+	"terraform-provider-multy/multy/common"
+	"testing"
+)
+
+func TestImportWithAccount(t *testing.T) {
+	h := newPlanHarness(t, "aws")
+	config := map[string]any{"name": "vn", "cidr_block": "10.0.0.0/16", "cloud": "aws", "location": "eu_west_1"}
+	h.apply("multy_virtual_network.vn", config)
+	id := h.ids["multy_virtual_network.vn"]
+
+	if errs := h.importState("multy_virtual_network.other", "other/"+id); errs != "" {
+		t.Fatalf("unable to import with an account, %s", errs)
+	}
+	if got := valueAt(h.states["multy_virtual_network.other"], tftypes.NewAttributePath().WithAttributeName("account")); !got.Equal(tftypes.NewValue(tftypes.String, "other")) {
+		t.Errorf("expected account to be set in the imported state, got %s", got)
+	}
+	if got := h.ids["multy_virtual_network.other"]; got != id {
+		t.Errorf("expected resource %s to be imported, got %s", id, got)
+	}
+	if got := h.plan("multy_virtual_network.other", withEdit(config, map[string]any{"account": "other"})); got != "no-op" {
+		t.Errorf("expected no changes after importing with an account, got %s", got)
+	}
+	if creds := h.fake.getCreds("ReadVirtualNetwork"); creds.GetAwsCreds().GetAccessKey() != "other" {
+		t.Errorf("expected the resource to be imported with the credentials of the account, got %s", creds.GetAwsCreds().GetAccessKey())
+	}
+
+	if errs := h.importState("multy_virtual_network.missing", "missing/"+id); errs != "Unknown account" {
+		t.Errorf("expected importing with an unknown account to fail, got %q", errs)
+	}
+
+	// same has the same credentials as the top-level ones, so the resource doesn't need to be replaced
+	if errs := h.importState("multy_virtual_network.default", id); errs != "" {
+		t.Fatalf("unable to import, %s", errs)
+	}
+	if got := h.plan("multy_virtual_network.default", withEdit(config, map[string]any{"account": "same"})); got != "update" {
+		t.Errorf("expected setting an account with the same credentials to update the resource, got %s", got)
+	}
+	if got := h.plan("multy_virtual_network.default", withEdit(config, map[string]any{"account": "other"})); got != "replace (account)" {
+		t.Errorf("expected setting an account with other credentials to replace the resource, got %s", got)
+	}
+}
+
+func TestAccountRequiresReplace(t *testing.T) {
+	aws := &common.AwsConfig{AccessKeyId: "key", AccessKeySecret: "secret"}
+	c := &common.ProviderConfig{
+		Credentials: common.Credentials{Aws: aws},
+		Accounts: map[string]*common.Credentials{
+			"same":      {Aws: &common.AwsConfig{AccessKeyId: "key", AccessKeySecret: "secret"}},
+			"different": {Aws: &common.AwsConfig{AccessKeyId: "other-key", AccessKeySecret: "other-secret"}},
+			"same-aws":  {Aws: &common.AwsConfig{AccessKeyId: "key", AccessKeySecret: "secret"}, Azure: &common.AzureConfig{ClientId: "client"}},
+		},
+		Clouds: common.NewCloudRegistry(),
+	}
+	c.Clouds.Add("vn", commonpb.CloudProvider_AWS)
+
+	tests := []struct {
+		name       string
+		prior      tftypes.Value
+		plan       tftypes.Value
+		wantChange bool
+	}{
+		{name: "same account", prior: accountState("aws", "same"), plan: accountState("aws", "same")},
+		{name: "same credentials", prior: accountState("aws", ""), plan: accountState("aws", "same")},
+		{name: "different credentials", prior: accountState("aws", ""), plan: accountState("aws", "different"), wantChange: true},
+		{name: "different credentials of another cloud", prior: accountState("aws", ""), plan: accountState("aws", "same-aws")},
+		{name: "different credentials of the parent's cloud", prior: accountState("", "same"), plan: accountState("", "different"), wantChange: true},
+		{name: "unknown account", prior: accountState("aws", ""), plan: accountState("aws", "missing"), wantChange: true},
+		{
+			name:       "account not known yet",
+			prior:      accountState("aws", ""),
+			plan:       tftypes.NewValue(accountStateType, map[string]tftypes.Value{"cloud": tftypes.NewValue(tftypes.String, "aws"), "account": tftypes.NewValue(tftypes.String, tftypes.UnknownValue), "virtual_network_id": tftypes.NewValue(tftypes.String, nil)}),
+			wantChange: true,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := accountRequiresReplace(c, tc.prior, tc.plan); got != tc.wantChange {
+				t.Errorf("expected %t, got %t", tc.wantChange, got)
+			}
+		})
+	}
+}
+
+var accountStateType = tftypes.Object{AttributeTypes: map[string]tftypes.Type{"cloud": tftypes.String, "account": tftypes.String, "virtual_network_id": tftypes.String}}
+
+// accountState returns the state of a resource in the given cloud and account. Resources without a cloud are in the
+// cloud of their virtual network.
+func accountState(cloud, account string) tftypes.Value {
+	nullable := func(s string) tftypes.Value {
+		if s == "" {
+			return tftypes.NewValue(tftypes.String, nil)
+		}
+		return tftypes.NewValue(tftypes.String, s)
+	}
+	vn := ""
+	if cloud == "" {
+		vn = "vn"
+	}
+	return tftypes.NewValue(accountStateType, map[string]tftypes.Value{"cloud": nullable(cloud), "account": nullable(account), "virtual_network_id": nullable(vn)})
+}
@@ -12,6 +12,7 @@ import (
 	"golang.org/x/exp/maps"
 	"golang.org/x/exp/slices"
 	"google.golang.org/protobuf/reflect/protoreflect"
+	"math/big"
 	"strings"
 )
 
@@ -226,7 +227,7 @@ type nonEmptyValueValidator[T comparable] struct {
 }
 
 var NonEmptyStringValidator = nonEmptyValueValidator[string]{}
-var NonEmptyIntValidator = nonEmptyValueValidator[int]{}
+var NonEmptyIntValidator = nonEmptyNumberValidator{}
 
 func (n nonEmptyValueValidator[T]) Description(_ context.Context) string {
 	return ""
@@ -259,3 +260,41 @@ func (n nonEmptyValueValidator[T]) Validate(ctx context.Context, req tfsdk.Valid
 		)
 	}
 }
+
+// nonEmptyNumberValidator checks that numbers aren't 0. Numbers can only be read into a big.Float, which isn't
+// comparable, so they can't use nonEmptyValueValidator.
+type nonEmptyNumberValidator struct {
+}
+
+func (n nonEmptyNumberValidator) Description(_ context.Context) string {
+	return ""
+}
+
+func (n nonEmptyNumberValidator) MarkdownDescription(_ context.Context) string {
+	return ""
+}
+
+func (n nonEmptyNumberValidator) Validate(ctx context.Context, req tfsdk.ValidateAttributeRequest, resp *tfsdk.ValidateAttributeResponse) {
+	value, err := req.AttributeConfig.ToTerraformValue(ctx)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(req.AttributePath, "Invalid value", err.Error())
+		return
+	}
+	if !value.IsFullyKnown() || value.IsNull() {
+		return
+	}
+
+	val := new(big.Float)
+	if err := value.As(&val); err != nil {
+		resp.Diagnostics.AddAttributeError(req.AttributePath, "Invalid value", err.Error())
+		return
+	}
+
+	if val.Sign() == 0 {
+		resp.Diagnostics.AddAttributeError(
+			req.AttributePath,
+			"Invalid value",
+			fmt.Sprintf("value cannot be empty, but was \"%v\"", val),
+		)
+	}
+}
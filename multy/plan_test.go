@@ -0,0 +1,555 @@
+package multy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/providerserver"
+	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"testing"
+)
+
+var updateGolden = flag.Bool("update", false, "update the golden files of TestPlanActions")
+
+// planTest is read from tests/plans. For each cloud, the dependencies and the resource are applied against a fake
+// server, and then each edit of the resource config is planned on its own, recording whether it updates the resource in
+// place, replaces it or doesn't change it.
+//
+// "${cloud}" in configs is replaced with the cloud being tested and "${<address>.id}" with the id of a dependency.
+type planTest struct {
+	Clouds       []string           `json:"clouds"`
+	Dependencies []planTestResource `json:"dependencies"`
+	Resource     planTestResource   `json:"resource"`
+	// Edits set attributes of the resource config, given as dot-separated paths. Null values remove the attribute.
+	Edits []map[string]any `json:"edits"`
+}
+
+type planTestResource struct {
+	Address string         `json:"address"`
+	Config  map[string]any `json:"config"`
+}
+
+func TestPlanActions(t *testing.T) {
+	files, err := filepath.Glob("../tests/plans/*.json")
+	if err != nil {
+		t.Fatalf("unable to get test files, %s", err)
+	}
+	for _, file := range files {
+		file := file
+		t.Run(strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+			b, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatalf("unable to open %s, %s", file, err)
+			}
+			var test planTest
+			if err := json.Unmarshal(b, &test); err != nil {
+				t.Fatalf("unable to parse %s, %s", file, err)
+			}
+
+			var got strings.Builder
+			for _, cloud := range test.Clouds {
+				fmt.Fprintf(&got, "# %s\n", cloud)
+				h := newPlanHarness(t, cloud)
+				for _, dep := range test.Dependencies {
+					h.apply(dep.Address, dep.Config)
+				}
+				h.apply(test.Resource.Address, test.Resource.Config)
+				fmt.Fprintf(&got, "(no changes): %s\n", h.plan(test.Resource.Address, test.Resource.Config))
+				for _, edit := range test.Edits {
+					config := withEdit(test.Resource.Config, edit)
+					fmt.Fprintf(&got, "%s: %s\n", describeEdit(edit), h.plan(test.Resource.Address, config))
+				}
+			}
+
+			golden := strings.TrimSuffix(file, ".json") + ".golden"
+			if *updateGolden {
+				if err := os.WriteFile(golden, []byte(got.String()), 0644); err != nil {
+					t.Fatalf("unable to write %s, %s", golden, err)
+				}
+				return
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("unable to open %s, %s (run with -update to create it)", golden, err)
+			}
+			if got.String() != string(want) {
+				t.Errorf("plan actions don't match %s (run with -update if the change is expected)\ngot:\n%s\nwant:\n%s", golden, got.String(), want)
+			}
+		})
+	}
+}
+
+// planHarness plans and applies resources the same way terraform does, by calling the provider server directly.
+type planHarness struct {
+	t       *testing.T
+	ctx     context.Context
+	cloud   string
+	fake    *fakeServer
+	server  tfprotov6.ProviderServer
+	schemas map[string]*tfprotov6.Schema
+	ids     map[string]string
+	states  map[string]tftypes.Value
+}
+
+func newPlanHarness(t *testing.T, cloud string) *planHarness {
+	fake := newFakeServer(t)
+	p := &Provider{dialer: fake.dial}
+	server, err := providerserver.NewProtocol6WithError(p)()
+	if err != nil {
+		t.Fatalf("unable to create provider server, %s", err)
+	}
+	h := &planHarness{
+		t:      t,
+		ctx:    context.Background(),
+		cloud:  cloud,
+		fake:   fake,
+		server: server,
+		ids:    map[string]string{},
+		states: map[string]tftypes.Value{},
+	}
+
+	schemaResp, err := server.GetProviderSchema(h.ctx, &tfprotov6.GetProviderSchemaRequest{})
+	h.check("get provider schema", err, schemaResp.Diagnostics)
+	h.schemas = schemaResp.ResourceSchemas
+
+	aws := map[string]any{"access_key_id": "fake", "access_key_secret": "fake"}
+	azure := map[string]any{"subscription_id": "fake", "client_id": "fake", "client_secret": "fake", "tenant_id": "fake"}
+	gcp := map[string]any{"credentials": `{"type":"service_account"}`, "project": "multy-project"}
+	// the other account has different credentials than the top-level ones, and the same account has the same ones
+	config := h.dynamicValue(schemaResp.Provider, map[string]any{
+		"aws":             aws,
+		"azure":           azure,
+		"gcp":             gcp,
+		"aws_accounts":    map[string]any{"other": withEdit(aws, map[string]any{"access_key_id": "other"}), "same": aws},
+		"azure_accounts":  map[string]any{"other": withEdit(azure, map[string]any{"client_id": "other"}), "same": azure},
+		"gcp_accounts":    map[string]any{"other": withEdit(gcp, map[string]any{"project": "other-project"}), "same": gcp},
+		"server_endpoint": "fake",
+		"api_key":         "fake",
+	})
+	configureResp, err := server.ConfigureProvider(h.ctx, &tfprotov6.ConfigureProviderRequest{Config: &config})
+	h.check("configure provider", err, configureResp.Diagnostics)
+	return h
+}
+
+// apply creates the resource with the given address and keeps its state and id.
+func (h *planHarness) apply(address string, config map[string]any) {
+	typeName, schema := h.schema(address)
+	configValue := h.dynamicValue(schema, config)
+	prior := h.dynamicValue(schema, nil)
+
+	validateResp, err := h.server.ValidateResourceConfig(h.ctx, &tfprotov6.ValidateResourceConfigRequest{TypeName: typeName, Config: &configValue})
+	h.check("validate "+address, err, validateResp.Diagnostics)
+	planResp, err := h.server.PlanResourceChange(h.ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       &prior,
+		ProposedNewState: &configValue,
+		Config:           &configValue,
+	})
+	h.check("plan "+address, err, planResp.Diagnostics)
+	applyResp, err := h.server.ApplyResourceChange(h.ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   &prior,
+		PlannedState: planResp.PlannedState,
+		Config:       &configValue,
+	})
+	h.check("apply "+address, err, applyResp.Diagnostics)
+
+	state, err := applyResp.NewState.Unmarshal(schema.ValueType())
+	if err != nil {
+		h.t.Fatalf("unable to decode state of %s, %s", address, err)
+	}
+	values := map[string]tftypes.Value{}
+	var id string
+	if err := state.As(&values); err != nil {
+		h.t.Fatalf("unable to decode state of %s, %s", address, err)
+	}
+	if err := values["id"].As(&id); err != nil {
+		h.t.Fatalf("unable to decode id of %s, %s", address, err)
+	}
+	h.states[address] = state
+	h.ids[address] = id
+}
+
+// update plans and applies the given config of an applied resource in place, and returns the planned state.
+func (h *planHarness) update(address string, config map[string]any) tftypes.Value {
+	typeName, schema := h.schema(address)
+	configValue := h.dynamicValue(schema, config)
+	configRaw, err := configValue.Unmarshal(schema.ValueType())
+	if err != nil {
+		h.t.Fatalf("unable to decode config of %s, %s", address, err)
+	}
+	proposed, err := tfprotov6.NewDynamicValue(schema.ValueType(), proposedNewState(schema.Block, h.states[address], configRaw))
+	if err != nil {
+		h.t.Fatalf("unable to encode proposed state of %s, %s", address, err)
+	}
+	prior, err := tfprotov6.NewDynamicValue(schema.ValueType(), h.states[address])
+	if err != nil {
+		h.t.Fatalf("unable to encode state of %s, %s", address, err)
+	}
+
+	planResp, err := h.server.PlanResourceChange(h.ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       &prior,
+		ProposedNewState: &proposed,
+		Config:           &configValue,
+	})
+	h.check("plan "+address, err, planResp.Diagnostics)
+	applyResp, err := h.server.ApplyResourceChange(h.ctx, &tfprotov6.ApplyResourceChangeRequest{
+		TypeName:     typeName,
+		PriorState:   &prior,
+		PlannedState: planResp.PlannedState,
+		Config:       &configValue,
+	})
+	h.check("apply "+address, err, applyResp.Diagnostics)
+
+	planned, err := planResp.PlannedState.Unmarshal(schema.ValueType())
+	if err != nil {
+		h.t.Fatalf("unable to decode plan of %s, %s", address, err)
+	}
+	state, err := applyResp.NewState.Unmarshal(schema.ValueType())
+	if err != nil {
+		h.t.Fatalf("unable to decode state of %s, %s", address, err)
+	}
+	h.states[address] = state
+	return planned
+}
+
+// read refreshes the state of an applied resource and returns the diagnostics of the read. The state is kept if the
+// read didn't fail.
+func (h *planHarness) read(address string) []*tfprotov6.Diagnostic {
+	typeName, schema := h.schema(address)
+	state, err := tfprotov6.NewDynamicValue(schema.ValueType(), h.states[address])
+	if err != nil {
+		h.t.Fatalf("unable to encode state of %s, %s", address, err)
+	}
+	resp, err := h.server.ReadResource(h.ctx, &tfprotov6.ReadResourceRequest{TypeName: typeName, CurrentState: &state})
+	if err != nil {
+		h.t.Fatalf("unable to read %s, %s", address, err)
+	}
+	if diagnosticErrors(resp.Diagnostics) != "" {
+		return resp.Diagnostics
+	}
+	newState, err := resp.NewState.Unmarshal(schema.ValueType())
+	if err != nil {
+		h.t.Fatalf("unable to decode state of %s, %s", address, err)
+	}
+	h.states[address] = newState
+	return resp.Diagnostics
+}
+
+// importState imports the resource with the given import id into address, keeping its state and id. It returns the
+// errors of the import, if any.
+func (h *planHarness) importState(address string, importId string) string {
+	typeName, schema := h.schema(address)
+	resp, err := h.server.ImportResourceState(h.ctx, &tfprotov6.ImportResourceStateRequest{TypeName: typeName, ID: importId})
+	if err != nil {
+		h.t.Fatalf("unable to import %s, %s", address, err)
+	}
+	if errs := diagnosticErrors(resp.Diagnostics); errs != "" {
+		return errs
+	}
+	state, err := resp.ImportedResources[0].State.Unmarshal(schema.ValueType())
+	if err != nil {
+		h.t.Fatalf("unable to decode state of %s, %s", address, err)
+	}
+	values := map[string]tftypes.Value{}
+	var id string
+	if err := state.As(&values); err != nil {
+		h.t.Fatalf("unable to decode state of %s, %s", address, err)
+	}
+	if err := values["id"].As(&id); err != nil {
+		h.t.Fatalf("unable to decode id of %s, %s", address, err)
+	}
+	h.states[address] = state
+	h.ids[address] = id
+	return ""
+}
+
+// plan plans the given config of a resource and returns the action terraform would take. Resources that weren't applied
+// are planned to be created.
+func (h *planHarness) plan(address string, config map[string]any) string {
+	action, _ := h.planWithWarnings(address, config)
+	return action
+}
+
+// planWithWarnings is like plan, but also returns the warnings of the plan.
+func (h *planHarness) planWithWarnings(address string, config map[string]any) (string, string) {
+	typeName, schema := h.schema(address)
+	configValue := h.dynamicValue(schema, config)
+	prior, ok := h.states[address]
+	if !ok {
+		prior = tftypes.NewValue(schema.ValueType(), nil)
+	}
+	configRaw, err := configValue.Unmarshal(schema.ValueType())
+	if err != nil {
+		h.t.Fatalf("unable to decode config of %s, %s", address, err)
+	}
+	proposed, err := tfprotov6.NewDynamicValue(schema.ValueType(), proposedNewState(schema.Block, prior, configRaw))
+	if err != nil {
+		h.t.Fatalf("unable to encode proposed state of %s, %s", address, err)
+	}
+	priorValue, err := tfprotov6.NewDynamicValue(schema.ValueType(), prior)
+	if err != nil {
+		h.t.Fatalf("unable to encode state of %s, %s", address, err)
+	}
+
+	if resp, err := h.server.ValidateResourceConfig(h.ctx, &tfprotov6.ValidateResourceConfigRequest{TypeName: typeName, Config: &configValue}); err != nil {
+		h.t.Fatalf("unable to validate %s, %s", address, err)
+	} else if errs := diagnosticErrors(resp.Diagnostics); errs != "" {
+		return "error: " + errs, ""
+	}
+	resp, err := h.server.PlanResourceChange(h.ctx, &tfprotov6.PlanResourceChangeRequest{
+		TypeName:         typeName,
+		PriorState:       &priorValue,
+		ProposedNewState: &proposed,
+		Config:           &configValue,
+	})
+	if err != nil {
+		h.t.Fatalf("unable to plan %s, %s", address, err)
+	}
+	warnings := diagnosticWarnings(resp.Diagnostics)
+	if errs := diagnosticErrors(resp.Diagnostics); errs != "" {
+		return "error: " + errs, warnings
+	}
+	planned, err := resp.PlannedState.Unmarshal(schema.ValueType())
+	if err != nil {
+		h.t.Fatalf("unable to decode plan of %s, %s", address, err)
+	}
+	if planned.Equal(prior) {
+		return "no-op", warnings
+	}
+	if prior.IsNull() {
+		return "create", warnings
+	}
+
+	// like terraform, only attributes that changed cause a replacement
+	var replaced []string
+	for _, p := range resp.RequiresReplace {
+		if !valueAt(prior, p).Equal(valueAt(planned, p)) {
+			replaced = append(replaced, formatPath(p))
+		}
+	}
+	if len(replaced) > 0 {
+		sort.Strings(replaced)
+		return fmt.Sprintf("replace (%s)", strings.Join(replaced, ", ")), warnings
+	}
+	return "update", warnings
+}
+
+func (h *planHarness) schema(address string) (string, *tfprotov6.Schema) {
+	typeName, _, _ := strings.Cut(address, ".")
+	schema, ok := h.schemas[typeName]
+	if !ok {
+		h.t.Fatalf("unknown resource type %s", typeName)
+	}
+	return typeName, schema
+}
+
+// dynamicValue encodes config, after replacing the cloud and the ids of dependencies.
+func (h *planHarness) dynamicValue(schema *tfprotov6.Schema, config map[string]any) tfprotov6.DynamicValue {
+	b, err := json.Marshal(config)
+	if err != nil {
+		h.t.Fatalf("unable to encode config, %s", err)
+	}
+	replacements := []string{"${cloud}", h.cloud}
+	for address, id := range h.ids {
+		replacements = append(replacements, fmt.Sprintf("${%s.id}", address), id)
+	}
+	b = []byte(strings.NewReplacer(replacements...).Replace(string(b)))
+	value, err := tftypes.ValueFromJSON(b, schema.ValueType())
+	if err != nil {
+		h.t.Fatalf("invalid config %s, %s", b, err)
+	}
+	dv, err := tfprotov6.NewDynamicValue(schema.ValueType(), withEmptyBlocks(schema.Block, value))
+	if err != nil {
+		h.t.Fatalf("unable to encode config, %s", err)
+	}
+	return dv
+}
+
+// withEmptyBlocks replaces blocks missing from config with empty lists, as terraform never sends null blocks.
+func withEmptyBlocks(block *tfprotov6.SchemaBlock, config tftypes.Value) tftypes.Value {
+	if config.IsNull() || len(block.BlockTypes) == 0 {
+		return config
+	}
+	values := map[string]tftypes.Value{}
+	_ = config.As(&values)
+	result := map[string]tftypes.Value{}
+	for name, v := range values {
+		result[name] = v
+	}
+	for _, b := range block.BlockTypes {
+		v := values[b.TypeName]
+		if v.IsNull() {
+			result[b.TypeName] = tftypes.NewValue(v.Type(), []tftypes.Value{})
+			continue
+		}
+		var elems []tftypes.Value
+		_ = v.As(&elems)
+		for i := range elems {
+			elems[i] = withEmptyBlocks(b.Block, elems[i])
+		}
+		result[b.TypeName] = tftypes.NewValue(v.Type(), elems)
+	}
+	return tftypes.NewValue(config.Type(), result)
+}
+
+func (h *planHarness) check(action string, err error, diags []*tfprotov6.Diagnostic) {
+	if err != nil {
+		h.t.Fatalf("unable to %s, %s", action, err)
+	}
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			h.t.Fatalf("unable to %s, %s: %s", action, d.Summary, d.Detail)
+		}
+	}
+}
+
+func diagnosticErrors(diags []*tfprotov6.Diagnostic) string {
+	var errs []string
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityError {
+			errs = append(errs, d.Summary)
+		}
+	}
+	return strings.Join(errs, "; ")
+}
+
+func diagnosticWarnings(diags []*tfprotov6.Diagnostic) string {
+	var warnings []string
+	for _, d := range diags {
+		if d.Severity == tfprotov6.DiagnosticSeverityWarning {
+			warnings = append(warnings, d.Summary)
+		}
+	}
+	return strings.Join(warnings, "; ")
+}
+
+// proposedNewState merges config with the prior state like terraform does before planning: computed attributes that
+// aren't set in config keep their prior value.
+func proposedNewState(block *tfprotov6.SchemaBlock, prior, config tftypes.Value) tftypes.Value {
+	if prior.IsNull() || config.IsNull() || !config.IsKnown() {
+		return config
+	}
+	priorValues, configValues := map[string]tftypes.Value{}, map[string]tftypes.Value{}
+	_ = prior.As(&priorValues)
+	_ = config.As(&configValues)
+
+	values := map[string]tftypes.Value{}
+	for name, v := range configValues {
+		values[name] = v
+	}
+	for _, a := range block.Attributes {
+		values[a.Name] = proposedNewAttribute(a, priorValues[a.Name], configValues[a.Name])
+	}
+	for _, b := range block.BlockTypes {
+		if b.Nesting == tfprotov6.SchemaNestedBlockNestingModeList {
+			values[b.TypeName] = proposedNewList(priorValues[b.TypeName], configValues[b.TypeName], func(prior, config tftypes.Value) tftypes.Value {
+				return proposedNewState(b.Block, prior, config)
+			})
+		}
+	}
+	return tftypes.NewValue(config.Type(), values)
+}
+
+func proposedNewAttribute(a *tfprotov6.SchemaAttribute, prior, config tftypes.Value) tftypes.Value {
+	if a.Computed && config.IsNull() {
+		return prior
+	}
+	if a.NestedType == nil || prior.IsNull() || config.IsNull() {
+		return config
+	}
+	attributes := &tfprotov6.SchemaBlock{Attributes: a.NestedType.Attributes}
+	switch a.NestedType.Nesting {
+	case tfprotov6.SchemaObjectNestingModeSingle:
+		return proposedNewState(attributes, prior, config)
+	case tfprotov6.SchemaObjectNestingModeList:
+		return proposedNewList(prior, config, func(prior, config tftypes.Value) tftypes.Value {
+			return proposedNewState(attributes, prior, config)
+		})
+	}
+	return config
+}
+
+// proposedNewList merges the elements of config with the prior elements at the same index.
+func proposedNewList(prior, config tftypes.Value, f func(prior, config tftypes.Value) tftypes.Value) tftypes.Value {
+	if prior.IsNull() || config.IsNull() || !config.IsKnown() {
+		return config
+	}
+	var priorElems, configElems []tftypes.Value
+	_ = prior.As(&priorElems)
+	_ = config.As(&configElems)
+	elems := make([]tftypes.Value, len(configElems))
+	for i, v := range configElems {
+		elems[i] = v
+		if i < len(priorElems) {
+			elems[i] = f(priorElems[i], v)
+		}
+	}
+	return tftypes.NewValue(config.Type(), elems)
+}
+
+// valueAt returns the value at p, or null if there's no value at p.
+func valueAt(v tftypes.Value, p *tftypes.AttributePath) tftypes.Value {
+	got, _, err := tftypes.WalkAttributePath(v, p)
+	if err != nil {
+		return tftypes.Value{}
+	}
+	if value, ok := got.(tftypes.Value); ok {
+		return value
+	}
+	return tftypes.Value{}
+}
+
+// withEdit returns a copy of config with the attributes of edit set.
+func withEdit(config map[string]any, edit map[string]any) map[string]any {
+	var result map[string]any
+	b, _ := json.Marshal(config)
+	_ = json.Unmarshal(b, &result)
+	for key, value := range edit {
+		names := strings.Split(key, ".")
+		m := result
+		for _, name := range names[:len(names)-1] {
+			next, ok := m[name].(map[string]any)
+			if !ok {
+				next = map[string]any{}
+				m[name] = next
+			}
+			m = next
+		}
+		if value == nil {
+			delete(m, names[len(names)-1])
+		} else {
+			m[names[len(names)-1]] = value
+		}
+	}
+	return result
+}
+
+func describeEdit(edit map[string]any) string {
+	var keys []string
+	for key := range edit {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	var parts []string
+	for _, key := range keys {
+		if edit[key] == nil {
+			parts = append(parts, "unset "+key)
+			continue
+		}
+		var b bytes.Buffer
+		enc := json.NewEncoder(&b)
+		enc.SetEscapeHTML(false)
+		_ = enc.Encode(edit[key])
+		parts = append(parts, fmt.Sprintf("%s = %s", key, strings.TrimSpace(b.String())))
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,86 @@
+package multy
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/multycloud/multy/api/proto/commonpb"
+	"strings"
+	"terraform-provider-multy/multy/common"
+	"terraform-provider-multy/multy/mtypes"
+)
+
+// validateVirtualNetworkReference checks that the virtual network with the given id, if known, is in the same cloud and
+// location as the resource referencing it.
+func validateVirtualNetworkReference(networks *common.NetworkRegistry, cloud mtypes.EnumValue[commonpb.CloudProvider], location mtypes.EnumValue[commonpb.Location], vnId types.String) diag.Diagnostics {
+	if !isKnown(vnId) {
+		return nil
+	}
+	vn, ok := networks.GetNetwork(vnId.ValueString())
+	if !ok {
+		return nil
+	}
+	return validateSameCloudAndLocation(path.Root("virtual_network_id"), "virtual network "+vn.Id, vn, cloud, location)
+}
+
+// validateSubnetReference checks that the virtual network of the subnet with the given id, if known, is in the same
+// cloud and location as the resource referencing the subnet.
+func validateSubnetReference(networks *common.NetworkRegistry, cloud mtypes.EnumValue[commonpb.CloudProvider], location mtypes.EnumValue[commonpb.Location], subnetId types.String) diag.Diagnostics {
+	if !isKnown(subnetId) {
+		return nil
+	}
+	vn, ok := networks.GetSubnetNetwork(subnetId.ValueString())
+	if !ok {
+		return nil
+	}
+	return validateSameCloudAndLocation(path.Root("subnet_id"), fmt.Sprintf("subnet %s (virtual network %s)", subnetId.ValueString(), vn.Id), vn, cloud, location)
+}
+
+func validateSameCloudAndLocation(attrPath path.Path, referenced string, vn common.NetworkInfo, cloud mtypes.EnumValue[commonpb.CloudProvider], location mtypes.EnumValue[commonpb.Location]) diag.Diagnostics {
+	var diags diag.Diagnostics
+	if c := enumString(cloud); c != "" && vn.Cloud != "" && !strings.EqualFold(c, vn.Cloud) {
+		diags.AddAttributeError(
+			attrPath,
+			"Cloud mismatch",
+			fmt.Sprintf("Resource is deployed in %s, but %s is deployed in %s.", c, referenced, vn.Cloud),
+		)
+	}
+	if l := enumString(location); l != "" && vn.Location != "" && !strings.EqualFold(l, vn.Location) {
+		diags.AddAttributeError(
+			attrPath,
+			"Location mismatch",
+			fmt.Sprintf("Resource is deployed in %s, but %s is deployed in %s.", l, referenced, vn.Location),
+		)
+	}
+	return diags
+}
+
+func isKnown(v types.String) bool {
+	return !v.IsNull() && !v.IsUnknown()
+}
+
+// knownString returns the value of v, or an empty string if it isn't known yet.
+func knownString(v types.String) string {
+	if !isKnown(v) {
+		return ""
+	}
+	return v.ValueString()
+}
+
+// enumString returns the value of v as it's shown to the user, or an empty string if it isn't known yet.
+func enumString[T mtypes.ProtoEnum](v mtypes.EnumValue[T]) string {
+	if v.IsNull() || v.IsUnknown() {
+		return ""
+	}
+	tfValue, err := v.ToTerraformValue(context.Background())
+	if err != nil {
+		return ""
+	}
+	var s string
+	if err := tfValue.As(&s); err != nil {
+		return ""
+	}
+	return s
+}
@@ -0,0 +1,91 @@
+package multy
+
+import (
+	"testing"
+)
+
+func TestSubnetOverlapValidation(t *testing.T) {
+	h := newPlanHarness(t, "aws")
+	vn := map[string]any{"name": "vn", "cidr_block": "10.0.0.0/16", "cloud": "aws", "location": "eu_west_1"}
+	h.apply("multy_virtual_network.vn", vn)
+	a := map[string]any{"name": "subnet-a", "cidr_block": "10.0.1.0/24", "virtual_network_id": h.ids["multy_virtual_network.vn"]}
+	b := map[string]any{"name": "subnet-b", "cidr_block": "10.0.2.0/24", "virtual_network_id": h.ids["multy_virtual_network.vn"]}
+	h.apply("multy_subnet.a", a)
+	h.apply("multy_subnet.b", b)
+
+	tests := []struct {
+		name         string
+		address      string
+		config       map[string]any
+		want         string
+		wantWarnings string
+	}{
+		{
+			name:         "overlaps with another subnet",
+			address:      "multy_subnet.a",
+			config:       withEdit(a, map[string]any{"cidr_block": "10.0.2.128/25"}),
+			want:         "replace (cidr_block)",
+			wantWarnings: "Overlapping subnets",
+		},
+		{
+			name:    "replaced subnet doesn't overlap with itself",
+			address: "multy_subnet.a",
+			config:  withEdit(a, map[string]any{"cidr_block": "10.0.1.0/25"}),
+			want:    "replace (cidr_block)",
+		},
+		{
+			name:         "outside of the virtual network",
+			address:      "multy_subnet.b",
+			config:       withEdit(b, map[string]any{"cidr_block": "10.1.0.0/24"}),
+			want:         "replace (cidr_block)",
+			wantWarnings: "Subnet outside of virtual network",
+		},
+		{
+			// subnet b can be destroyed in the same plan
+			name:         "created with the CIDR block of another subnet",
+			address:      "multy_subnet.c",
+			config:       withEdit(b, map[string]any{"name": "subnet-c"}),
+			want:         "create",
+			wantWarnings: "Overlapping subnets",
+		},
+		{
+			// the virtual network can be widened in the same plan
+			name:         "created outside of the virtual network",
+			address:      "multy_subnet.c",
+			config:       withEdit(b, map[string]any{"name": "subnet-c", "cidr_block": "10.1.0.0/24"}),
+			want:         "create",
+			wantWarnings: "Subnet outside of virtual network",
+		},
+		{
+			name:    "virtual network widened",
+			address: "multy_virtual_network.vn",
+			config:  withEdit(vn, map[string]any{"cidr_block": "10.0.0.0/15"}),
+			want:    "replace (cidr_block)",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, warnings := h.planWithWarnings(tc.address, tc.config)
+			if got != tc.want {
+				t.Errorf("expected %q, got %q", tc.want, got)
+			}
+			if warnings != tc.wantWarnings {
+				t.Errorf("expected warnings %q, got %q", tc.wantWarnings, warnings)
+			}
+		})
+	}
+
+	// subnets that swap CIDR blocks can be planned in any order, and planning doesn't register planned values
+	for _, order := range [][]string{{"multy_subnet.a", "multy_subnet.b"}, {"multy_subnet.b", "multy_subnet.a"}} {
+		for _, address := range order {
+			config := map[string]map[string]any{
+				"multy_subnet.a": withEdit(a, map[string]any{"cidr_block": b["cidr_block"]}),
+				"multy_subnet.b": withEdit(b, map[string]any{"cidr_block": a["cidr_block"]}),
+			}[address]
+			got, warnings := h.planWithWarnings(address, config)
+			if got != "replace (cidr_block)" || warnings != "Overlapping subnets" {
+				t.Errorf("planning %v: expected %s to be replaced with a warning, got %q (warnings %q)", order, address, got, warnings)
+			}
+		}
+	}
+}
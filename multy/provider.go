@@ -2,36 +2,71 @@ package multy
 
 import (
 	"context"
+	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/datasource"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/mitchellh/go-homedir"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc/credentials"
 	"io/ioutil"
+	"net"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"terraform-provider-multy/multy/common"
+	"terraform-provider-multy/multy/mtypes"
+	"terraform-provider-multy/multy/validators"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	awscfg "github.com/aws/aws-sdk-go-v2/config"
+	awscreds "github.com/aws/aws-sdk-go-v2/credentials"
+	"github.com/aws/aws-sdk-go-v2/credentials/stscreds"
+	"github.com/aws/aws-sdk-go-v2/service/sts"
 	"github.com/hashicorp/go-azure-helpers/authentication"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
 	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/multycloud/multy/api/proto"
+	"github.com/multycloud/multy/api/proto/commonpb"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/credentials/insecure"
 )
 
 type connectionCache struct {
 	sync.Mutex
-	cache map[string]proto.MultyResourceServiceClient
+	cache map[connectionKey]proto.MultyResourceServiceClient
 }
 
-var connCache = connectionCache{cache: map[string]proto.MultyResourceServiceClient{}}
-var refreshCache = &common.RefreshCache{}
+// connectionKey holds every setting that is used when connecting to the server, so providers with different settings
+// don't share connections
+type connectionKey struct {
+	endpoint string
+	retry    common.RetryConfig
+	tls      tlsSettings
+}
+
+type tlsSettings struct {
+	insecure       bool
+	caCertFile     string
+	clientCertFile string
+	clientKeyFile  string
+	serverName     string
+}
+
+var connCache = connectionCache{cache: map[connectionKey]proto.MultyResourceServiceClient{}}
+
+// refreshTtl is how long the refreshed state of a cloud is used before it's refreshed again.
+const refreshTtl = 5 * time.Minute
 
 func New() provider.Provider {
 	return &Provider{}
@@ -40,6 +75,9 @@ func New() provider.Provider {
 type Provider struct {
 	Configured bool
 	Client     *common.ProviderConfig
+
+	// dialer replaces the network connection to the multy server. It's only set in tests, to use an in-memory server.
+	dialer func(context.Context, string) (net.Conn, error)
 }
 
 var awsSchema = tfsdk.Attribute{
@@ -65,6 +103,43 @@ var awsSchema = tfsdk.Attribute{
 			Type:        types.StringType,
 			Sensitive:   true,
 		},
+		"profile": {
+			Optional:    true,
+			Description: "Name of the profile in the AWS shared config and credentials files to get credentials from. " + common.HelperValueViaEnvVar("AWS_PROFILE"),
+			Type:        types.StringType,
+		},
+		"shared_credentials_files": {
+			Optional:    true,
+			Description: "Paths to the AWS shared credentials files. Defaults to `~/.aws/credentials`",
+			Type:        types.ListType{ElemType: types.StringType},
+		},
+		"assume_role": {
+			Optional:    true,
+			Description: "Role to assume with the credentials above, which are exchanged for temporary credentials before being sent to the multy server",
+			Attributes: tfsdk.SingleNestedAttributes(map[string]tfsdk.Attribute{
+				"role_arn": {
+					Required:    true,
+					Description: "ARN of the role to assume",
+					Type:        types.StringType,
+				},
+				"session_name": {
+					Optional:    true,
+					Description: "Session name to use when assuming the role. Defaults to `multy-terraform-provider`",
+					Type:        types.StringType,
+				},
+				"external_id": {
+					Optional:    true,
+					Description: "External identifier to use when assuming the role",
+					Type:        types.StringType,
+				},
+				"duration": {
+					Optional:    true,
+					Description: "Duration of the role session, such as `1h`. Defaults to `15m`",
+					Type:        types.StringType,
+					Validators:  []tfsdk.AttributeValidator{validators.IsDurationValidator{}},
+				},
+			}),
+		},
 	}),
 }
 
@@ -110,12 +185,36 @@ var gcpSchema = tfsdk.Attribute{
 		},
 		"project": {
 			Optional:    true,
-			Description: "The project to manage resources in. " + common.HelperValueViaEnvVar("GOOGLE_CREDENTIALS"),
+			Description: "The project to manage resources in. Defaults to the project of the credentials. " + common.HelperValueViaEnvVar("GOOGLE_PROJECT"),
 			Type:        types.StringType,
 		},
+		"impersonate_service_account": {
+			Optional:    true,
+			Description: "Email of a service account to impersonate with the credentials above. " + common.HelperValueViaEnvVar("GOOGLE_IMPERSONATE_SERVICE_ACCOUNT"),
+			Type:        types.StringType,
+		},
+		"impersonate_service_account_delegates": {
+			Optional:    true,
+			Description: "Chain of service accounts used to impersonate `impersonate_service_account`, each one impersonating the next",
+			Type:        types.ListType{ElemType: types.StringType},
+		},
 	}),
 }
 
+// accountsSchema returns a map of named credential sets, each one with the same attributes as the given cloud's
+// credentials.
+func accountsSchema(credentials tfsdk.Attribute, cloud string) tfsdk.Attribute {
+	attrs := map[string]tfsdk.Attribute{}
+	for name, a := range credentials.Attributes.GetAttributes() {
+		attrs[name] = a.(tfsdk.Attribute)
+	}
+	return tfsdk.Attribute{
+		Optional:    true,
+		Description: fmt.Sprintf("Named sets of credentials, in addition to the ones in `%s`, that resources can select through their `account` attribute. Each set has the same attributes as `%s`", cloud, cloud),
+		Attributes:  tfsdk.MapNestedAttributes(attrs),
+	}
+}
+
 func (p *Provider) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
 	return tfsdk.Schema{
 		MarkdownDescription: "Terraform provider to manage the lifecycle of Multy resources.",
@@ -126,31 +225,124 @@ func (p *Provider) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics)
 				Optional:    true,
 				Sensitive:   true,
 			},
-			"aws":   awsSchema,
-			"azure": azureSchema,
-			"gcp":   gcpSchema,
+			"aws":            awsSchema,
+			"azure":          azureSchema,
+			"gcp":            gcpSchema,
+			"aws_accounts":   accountsSchema(awsSchema, "aws"),
+			"azure_accounts": accountsSchema(azureSchema, "azure"),
+			"gcp_accounts":   accountsSchema(gcpSchema, "gcp"),
+			"default_cloud": {
+				Type:        mtypes.CloudType,
+				Description: fmt.Sprintf("Cloud provider used by resources that don't set `cloud`. Accepted values are %s", common.StringSliceToDocsMarkdown(mtypes.CloudType.GetAllValues())),
+				Optional:    true,
+				Validators:  []tfsdk.AttributeValidator{validators.NewValidator(mtypes.CloudType)},
+			},
+			"default_location": {
+				Type:        mtypes.LocationType,
+				Description: "Location used by resources that don't set `location`. Read more about regions in [documentation](https://docs.multy.dev/regions)",
+				Optional:    true,
+				Validators:  []tfsdk.AttributeValidator{validators.NewValidator(mtypes.LocationType)},
+			},
 			"server_endpoint": {
 				Type:        types.StringType,
 				Description: "Address of the multy server. Defaults to `api.multy.dev`. If local, it will be run without SSL",
 				Optional:    true,
 				Sensitive:   true,
 			},
+			"validate_credentials": {
+				Type:        types.BoolType,
+				Description: "Check that the credentials of every configured cloud are valid when configuring the provider, instead of failing when resources are applied. Defaults to `false`",
+				Optional:    true,
+			},
+			"skip_refresh": {
+				Type:        types.BoolType,
+				Description: "Read resources from the last state known by the multy server instead of refreshing it from the clouds first. Plans are faster, but changes made outside of terraform aren't detected. Defaults to `false`. " + common.HelperValueViaEnvVar("MULTY_SKIP_REFRESH"),
+				Optional:    true,
+			},
+			"debug": {
+				Type:        types.BoolType,
+				Description: "Include internal error details from the multy server in error messages. Defaults to `false`. " + common.HelperValueViaEnvVar("MULTY_DEBUG"),
+				Optional:    true,
+			},
+			"insecure": {
+				Type:        types.BoolType,
+				Description: "Connect to the multy server without TLS. Defaults to `true` if the server endpoint starts with `localhost` and `false` otherwise",
+				Optional:    true,
+			},
+			"ca_cert_file": {
+				Type:        types.StringType,
+				Description: "Path to a PEM encoded CA certificate used to verify the multy server. Defaults to the system cert pool",
+				Optional:    true,
+			},
+			"client_cert_file": {
+				Type:        types.StringType,
+				Description: "Path to a PEM encoded client certificate used to authenticate with the multy server through mTLS. Must be set together with `client_key_file`",
+				Optional:    true,
+			},
+			"client_key_file": {
+				Type:        types.StringType,
+				Description: "Path to the PEM encoded private key of `client_cert_file`",
+				Optional:    true,
+			},
+			"tls_server_name": {
+				Type:        types.StringType,
+				Description: "Server name used to verify the certificate of the multy server. Defaults to the host in the server endpoint",
+				Optional:    true,
+			},
+			"max_retries": {
+				Type:        types.Int64Type,
+				Description: fmt.Sprintf("Maximum number of times a read or delete request is retried when the multy server is temporarily unavailable. Defaults to `%d`", common.DefaultMaxRetries),
+				Optional:    true,
+			},
+			"retry_max_interval": {
+				Type:        types.StringType,
+				Description: fmt.Sprintf("Maximum time to wait between retries, such as `10s` or `1m`. Defaults to `%s`", common.FormatDuration(common.DefaultRetryMaxInterval)),
+				Optional:    true,
+				Validators:  []tfsdk.AttributeValidator{validators.IsDurationValidator{}},
+			},
 		},
 	}, nil
 }
 
 type providerData struct {
-	ApiKey         types.String         `tfsdk:"api_key"`
-	ServerEndpoint types.String         `tfsdk:"server_endpoint"`
-	Aws            *providerAwsConfig   `tfsdk:"aws"`
-	Azure          *providerAzureConfig `tfsdk:"azure"`
-	Gcp            *providerGcpConfig   `tfsdk:"gcp"`
+	ApiKey           types.String         `tfsdk:"api_key"`
+	ServerEndpoint   types.String         `tfsdk:"server_endpoint"`
+	Insecure         types.Bool           `tfsdk:"insecure"`
+	CaCertFile       types.String         `tfsdk:"ca_cert_file"`
+	ClientCertFile   types.String         `tfsdk:"client_cert_file"`
+	ClientKeyFile    types.String         `tfsdk:"client_key_file"`
+	TlsServerName    types.String         `tfsdk:"tls_server_name"`
+	MaxRetries       types.Int64          `tfsdk:"max_retries"`
+	RetryMaxInterval types.String         `tfsdk:"retry_max_interval"`
+	Debug            types.Bool           `tfsdk:"debug"`
+	ValidateCreds    types.Bool           `tfsdk:"validate_credentials"`
+	SkipRefresh      types.Bool           `tfsdk:"skip_refresh"`
+	Aws              *providerAwsConfig   `tfsdk:"aws"`
+	Azure            *providerAzureConfig `tfsdk:"azure"`
+	Gcp              *providerGcpConfig   `tfsdk:"gcp"`
+
+	AwsAccounts   map[string]providerAwsConfig   `tfsdk:"aws_accounts"`
+	AzureAccounts map[string]providerAzureConfig `tfsdk:"azure_accounts"`
+	GcpAccounts   map[string]providerGcpConfig   `tfsdk:"gcp_accounts"`
+
+	DefaultCloud    mtypes.EnumValue[commonpb.CloudProvider] `tfsdk:"default_cloud"`
+	DefaultLocation mtypes.EnumValue[commonpb.Location]      `tfsdk:"default_location"`
 }
 
 type providerAwsConfig struct {
-	AccessKeyId     types.String `tfsdk:"access_key_id"`
-	AccessKeySecret types.String `tfsdk:"access_key_secret"`
-	SessionToken    types.String `tfsdk:"session_token"`
+	AccessKeyId            types.String                 `tfsdk:"access_key_id"`
+	AccessKeySecret        types.String                 `tfsdk:"access_key_secret"`
+	SessionToken           types.String                 `tfsdk:"session_token"`
+	Profile                types.String                 `tfsdk:"profile"`
+	SharedCredentialsFiles types.List                   `tfsdk:"shared_credentials_files"`
+	AssumeRole             *providerAwsAssumeRoleConfig `tfsdk:"assume_role"`
+}
+
+type providerAwsAssumeRoleConfig struct {
+	RoleArn     types.String `tfsdk:"role_arn"`
+	SessionName types.String `tfsdk:"session_name"`
+	ExternalId  types.String `tfsdk:"external_id"`
+	Duration    types.String `tfsdk:"duration"`
 }
 
 type providerAzureConfig struct {
@@ -161,8 +353,10 @@ type providerAzureConfig struct {
 }
 
 type providerGcpConfig struct {
-	Credentials types.String `tfsdk:"credentials"`
-	Project     types.String `tfsdk:"project"`
+	Credentials                        types.String `tfsdk:"credentials"`
+	Project                            types.String `tfsdk:"project"`
+	ImpersonateServiceAccount          types.String `tfsdk:"impersonate_service_account"`
+	ImpersonateServiceAccountDelegates types.List   `tfsdk:"impersonate_service_account_delegates"`
 }
 
 func (p *Provider) Configure(ctx context.Context, req provider.ConfigureRequest, resp *provider.ConfigureResponse) {
@@ -227,7 +421,7 @@ func (p *Provider) ConfigureProvider(ctx context.Context, config providerData, r
 
 	var gcpConfig *common.GcpConfig
 	if config.Gcp != nil {
-		gcpConfig, err = p.validateGcpConfig(config.Gcp)
+		gcpConfig, err = p.validateGcpConfig(ctx, config.Gcp)
 		if err != nil {
 			resp.Diagnostics.AddError(
 				"Unable to retrieve Google credentials.",
@@ -237,7 +431,46 @@ func (p *Provider) ConfigureProvider(ctx context.Context, config providerData, r
 		}
 	}
 
-	client := p.getConnToServer(config, resp)
+	accounts, diags := p.getAccounts(ctx, config)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+
+	if config.ValidateCreds.IsUnknown() {
+		resp.Diagnostics.AddError("Invalid validate_credentials configuration", "cannot use unknown value as validate_credentials")
+		return
+	}
+	if config.ValidateCreds.ValueBool() {
+		toValidate := map[string]*common.Credentials{"": {Aws: awsConfig, Azure: azureConfig, Gcp: gcpConfig}}
+		for name, account := range accounts {
+			toValidate[name] = account
+		}
+		resp.Diagnostics.Append(validateCredentials(ctx, toValidate)...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+
+	retryConfig, err := getRetryConfig(config)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid retry configuration", err.Error())
+		return
+	}
+
+	debug, err := getDebug(config)
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid debug configuration", err.Error())
+		return
+	}
+
+	skipRefresh, err := getBool(config.SkipRefresh, "skip_refresh", "MULTY_SKIP_REFRESH")
+	if err != nil {
+		resp.Diagnostics.AddError("Invalid skip_refresh configuration", err.Error())
+		return
+	}
+
+	client := p.getConnToServer(config, retryConfig, resp)
 	if resp.Diagnostics.HasError() {
 		return
 	}
@@ -248,7 +481,14 @@ func (p *Provider) ConfigureProvider(ctx context.Context, config providerData, r
 	c.Aws = awsConfig
 	c.Azure = azureConfig
 	c.Gcp = gcpConfig
-	c.RefreshCache = refreshCache
+	c.Accounts = accounts
+	c.RefreshCache = common.NewRefreshCache(refreshTtl, skipRefresh)
+	c.Networks = common.NewNetworkRegistry()
+	c.Clouds = common.NewCloudRegistry()
+	c.Types = common.NewTypeRegistry()
+	c.DefaultCloud = config.DefaultCloud.String()
+	c.DefaultLocation = config.DefaultLocation.String()
+	c.Debug = debug
 
 	ctx, err = c.AddHeaders(ctx)
 	if err != nil {
@@ -263,7 +503,7 @@ func (p *Provider) ConfigureProvider(ctx context.Context, config providerData, r
 	p.Configured = true
 }
 
-func (p *Provider) getConnToServer(config providerData, resp *provider.ConfigureResponse) proto.MultyResourceServiceClient {
+func (p *Provider) getConnToServer(config providerData, retryConfig common.RetryConfig, resp *provider.ConfigureResponse) proto.MultyResourceServiceClient {
 	connCache.Lock()
 	defer connCache.Unlock()
 
@@ -271,36 +511,256 @@ func (p *Provider) getConnToServer(config providerData, resp *provider.Configure
 	if !config.ServerEndpoint.IsNull() {
 		endpoint = config.ServerEndpoint.ValueString()
 	}
-	if _, ok := connCache.cache[endpoint]; !ok {
-		creds := insecure.NewCredentials()
-		if !strings.HasPrefix(endpoint, "localhost") {
-			cp, err := x509.SystemCertPool()
-			if err != nil {
-				resp.Diagnostics.AddError(
-					"Unable to create multy client",
-					"Unable to get system cert pool: "+err.Error(),
-				)
-				return nil
-			}
-			creds = credentials.NewClientTLSFromCert(cp, "")
-		}
+	if p.dialer != nil {
+		// in-memory servers aren't shared between providers, so their connections aren't cached
+		return dial(endpoint, retryConfig, resp, grpc.WithContextDialer(p.dialer), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
 
-		conn, err := grpc.Dial(endpoint, grpc.WithTransportCredentials(creds))
+	key := connectionKey{endpoint: endpoint, retry: retryConfig, tls: getTlsSettings(config, endpoint)}
+	if _, ok := connCache.cache[key]; !ok {
+		creds, err := getTransportCredentials(key.tls)
 		if err != nil {
 			resp.Diagnostics.AddError(
-				"Unable to create Client",
-				"Unable to create multy Client:\n\n"+err.Error(),
+				"Unable to create multy client",
+				"Unable to configure TLS: "+err.Error(),
 			)
 			return nil
 		}
 
-		connCache.cache[endpoint] = proto.NewMultyResourceServiceClient(conn)
+		client := dial(endpoint, retryConfig, resp, grpc.WithTransportCredentials(creds))
+		if resp.Diagnostics.HasError() {
+			return nil
+		}
+		connCache.cache[key] = client
 	}
 
-	client := connCache.cache[endpoint]
+	client := connCache.cache[key]
 	return client
 }
 
+func dial(endpoint string, retryConfig common.RetryConfig, resp *provider.ConfigureResponse, opts ...grpc.DialOption) proto.MultyResourceServiceClient {
+	// every retry is logged separately
+	opts = append(opts, grpc.WithChainUnaryInterceptor(common.RetryInterceptor(retryConfig), otelgrpc.UnaryClientInterceptor(), common.LoggingInterceptor()))
+	conn, err := grpc.Dial(endpoint, opts...)
+	if err != nil {
+		resp.Diagnostics.AddError(
+			"Unable to create Client",
+			"Unable to create multy Client:\n\n"+err.Error(),
+		)
+		return nil
+	}
+	return proto.NewMultyResourceServiceClient(conn)
+}
+
+func getTlsSettings(config providerData, endpoint string) tlsSettings {
+	settings := tlsSettings{
+		insecure:       strings.HasPrefix(endpoint, "localhost"),
+		caCertFile:     config.CaCertFile.ValueString(),
+		clientCertFile: config.ClientCertFile.ValueString(),
+		clientKeyFile:  config.ClientKeyFile.ValueString(),
+		serverName:     config.TlsServerName.ValueString(),
+	}
+	if !config.Insecure.IsNull() && !config.Insecure.IsUnknown() {
+		settings.insecure = config.Insecure.ValueBool()
+	}
+	return settings
+}
+
+func getTransportCredentials(settings tlsSettings) (credentials.TransportCredentials, error) {
+	if settings.insecure {
+		return insecure.NewCredentials(), nil
+	}
+
+	tlsConfig := &tls.Config{ServerName: settings.serverName}
+	if settings.caCertFile != "" {
+		caCert, err := readFile(settings.caCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read ca_cert_file: %w", err)
+		}
+		tlsConfig.RootCAs = x509.NewCertPool()
+		if !tlsConfig.RootCAs.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("ca_cert_file %s doesn't contain any PEM encoded certificate", settings.caCertFile)
+		}
+	} else {
+		cp, err := x509.SystemCertPool()
+		if err != nil {
+			return nil, fmt.Errorf("unable to get system cert pool: %w", err)
+		}
+		tlsConfig.RootCAs = cp
+	}
+
+	if (settings.clientCertFile == "") != (settings.clientKeyFile == "") {
+		return nil, fmt.Errorf("client_cert_file and client_key_file must be set together")
+	}
+	if settings.clientCertFile != "" {
+		certPem, err := readFile(settings.clientCertFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client_cert_file: %w", err)
+		}
+		keyPem, err := readFile(settings.clientKeyFile)
+		if err != nil {
+			return nil, fmt.Errorf("unable to read client_key_file: %w", err)
+		}
+		cert, err := tls.X509KeyPair(certPem, keyPem)
+		if err != nil {
+			return nil, fmt.Errorf("unable to load client certificate: %w", err)
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return credentials.NewTLS(tlsConfig), nil
+}
+
+func readFile(path string) ([]byte, error) {
+	path, err := homedir.Expand(path)
+	if err != nil {
+		return nil, err
+	}
+	return os.ReadFile(path)
+}
+
+func getRetryConfig(config providerData) (common.RetryConfig, error) {
+	retryConfig := common.RetryConfig{
+		MaxRetries:  common.DefaultMaxRetries,
+		MaxInterval: common.DefaultRetryMaxInterval,
+	}
+	if config.MaxRetries.IsUnknown() || config.RetryMaxInterval.IsUnknown() {
+		return retryConfig, fmt.Errorf("cannot use unknown value as max_retries or retry_max_interval")
+	}
+	if !config.MaxRetries.IsNull() {
+		if config.MaxRetries.ValueInt64() < 0 {
+			return retryConfig, fmt.Errorf("max_retries can't be negative")
+		}
+		retryConfig.MaxRetries = int(config.MaxRetries.ValueInt64())
+	}
+	if !config.RetryMaxInterval.IsNull() {
+		d, err := time.ParseDuration(config.RetryMaxInterval.ValueString())
+		if err != nil || d <= 0 {
+			return retryConfig, fmt.Errorf("retry_max_interval must be a positive duration, such as 30s")
+		}
+		retryConfig.MaxInterval = d
+	}
+	return retryConfig, nil
+}
+
+const credentialsValidationTimeout = 30 * time.Second
+
+// validateCredentials checks the credentials of every configured cloud in every account in parallel, returning an
+// error for each one that is invalid. The empty account holds the top-level credentials.
+func validateCredentials(ctx context.Context, accounts map[string]*common.Credentials) diag.Diagnostics {
+	type validator interface {
+		Validate(ctx context.Context) (string, error)
+	}
+	type check struct {
+		cloud     string
+		account   string
+		validator validator
+	}
+	var checks []check
+	for account, creds := range accounts {
+		if creds.Aws != nil {
+			checks = append(checks, check{cloud: "AWS", account: account, validator: creds.Aws})
+		}
+		if creds.Azure != nil {
+			checks = append(checks, check{cloud: "Azure", account: account, validator: creds.Azure})
+		}
+		if creds.Gcp != nil {
+			checks = append(checks, check{cloud: "Google", account: account, validator: creds.Gcp})
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, credentialsValidationTimeout)
+	defer cancel()
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	var diags diag.Diagnostics
+	for _, c := range checks {
+		c := c
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			identity, err := c.validator.Validate(ctx)
+			mu.Lock()
+			defer mu.Unlock()
+			name := c.cloud
+			if c.account != "" {
+				name = fmt.Sprintf("%s (account %s)", c.cloud, c.account)
+			}
+			if err != nil {
+				diags.AddError(fmt.Sprintf("Invalid %s credentials", name), fmt.Sprintf("Unable to authenticate to %s: %s", name, err.Error()))
+				return
+			}
+			tflog.Info(ctx, fmt.Sprintf("%s credentials are valid", name), map[string]interface{}{"identity": identity})
+		}()
+	}
+	wg.Wait()
+	return diags
+}
+
+// getAccounts parses the named credential sets in aws_accounts, azure_accounts and gcp_accounts. Sets with the same
+// name in different clouds make up a single account.
+func (p *Provider) getAccounts(ctx context.Context, config providerData) (map[string]*common.Credentials, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	accounts := map[string]*common.Credentials{}
+	getAccount := func(name string) *common.Credentials {
+		if _, ok := accounts[name]; !ok {
+			accounts[name] = &common.Credentials{}
+		}
+		return accounts[name]
+	}
+
+	for name, awsAccount := range config.AwsAccounts {
+		awsAccount := awsAccount
+		awsConfig, err := p.validateAwsConfig(ctx, &awsAccount)
+		if err != nil {
+			diags.AddAttributeError(path.Root("aws_accounts").AtMapKey(name), "Unable to retrieve AWS credentials.", err.Error())
+			continue
+		}
+		getAccount(name).Aws = awsConfig
+	}
+	for name, azureAccount := range config.AzureAccounts {
+		azureAccount := azureAccount
+		azureConfig, err := p.validateAzureConfig(&azureAccount)
+		if err != nil {
+			diags.AddAttributeError(path.Root("azure_accounts").AtMapKey(name), "Unable to retrieve Azure credentials.", err.Error())
+			continue
+		}
+		getAccount(name).Azure = azureConfig
+	}
+	for name, gcpAccount := range config.GcpAccounts {
+		gcpAccount := gcpAccount
+		gcpConfig, err := p.validateGcpConfig(ctx, &gcpAccount)
+		if err != nil {
+			diags.AddAttributeError(path.Root("gcp_accounts").AtMapKey(name), "Unable to retrieve Google credentials.", err.Error())
+			continue
+		}
+		getAccount(name).Gcp = gcpConfig
+	}
+	return accounts, diags
+}
+
+func getDebug(config providerData) (bool, error) {
+	return getBool(config.Debug, "debug", "MULTY_DEBUG")
+}
+
+// getBool returns the value of the given attribute, or of envVar if the attribute isn't set.
+func getBool(value types.Bool, attribute string, envVar string) (bool, error) {
+	if value.IsUnknown() {
+		return false, fmt.Errorf("cannot use unknown value as %s", attribute)
+	}
+	if !value.IsNull() {
+		return value.ValueBool(), nil
+	}
+	if env := os.Getenv(envVar); env != "" {
+		b, err := strconv.ParseBool(env)
+		if err != nil {
+			return false, fmt.Errorf("%s must be true or false, got %q", envVar, env)
+		}
+		return b, nil
+	}
+	return false, nil
+}
+
 func (p *Provider) Resources(ctx context.Context) []func() resource.Resource {
 	return []func() resource.Resource{
 		func() resource.Resource { return ResourceDatabaseType{}.NewResource(ctx, p) },
@@ -326,12 +786,20 @@ func (p *Provider) Resources(ctx context.Context) []func() resource.Resource {
 }
 
 // GetDataSources - Defines Provider data sources
-func (p *Provider) DataSources(_ context.Context) []func() datasource.DataSource {
-	return nil
+func (p *Provider) DataSources(ctx context.Context) []func() datasource.DataSource {
+	return []func() datasource.DataSource{
+		func() datasource.DataSource { return DataSourceDatabaseType{}.NewDataSource(ctx, p) },
+		func() datasource.DataSource { return DataSourceKubernetesClusterType{}.NewDataSource(ctx, p) },
+		func() datasource.DataSource { return DataSourceNetworkSecurityGroupType{}.NewDataSource(ctx, p) },
+		func() datasource.DataSource { return DataSourceObjectStorageType{}.NewDataSource(ctx, p) },
+		func() datasource.DataSource { return DataSourcePublicIpType{}.NewDataSource(ctx, p) },
+		func() datasource.DataSource { return DataSourceSubnetType{}.NewDataSource(ctx, p) },
+		func() datasource.DataSource { return DataSourceVaultType{}.NewDataSource(ctx, p) },
+		func() datasource.DataSource { return DataSourceVirtualNetworkType{}.NewDataSource(ctx, p) },
+	}
 }
 
 func (p *Provider) validateAwsConfig(ctx context.Context, config *providerAwsConfig) (*common.AwsConfig, error) {
-	var awsConfig common.AwsConfig
 	if config.AccessKeyId.IsUnknown() {
 		return nil, fmt.Errorf("cannot use unknown value as access_key_id")
 	}
@@ -341,32 +809,129 @@ func (p *Provider) validateAwsConfig(ctx context.Context, config *providerAwsCon
 	if config.SessionToken.IsUnknown() {
 		return nil, fmt.Errorf("cannot use unknown value as session_token")
 	}
-	awsConfig = common.AwsConfig{
+	if config.Profile.IsUnknown() || config.SharedCredentialsFiles.IsUnknown() {
+		return nil, fmt.Errorf("cannot use unknown value as profile or shared_credentials_files")
+	}
+
+	staticConfig := common.AwsConfig{
 		AccessKeyId:     config.AccessKeyId.ValueString(),
 		AccessKeySecret: config.AccessKeySecret.ValueString(),
 		SessionToken:    config.SessionToken.ValueString(),
 	}
-	if len(awsConfig.AccessKeyId) > 0 && len(awsConfig.AccessKeyId) > 0 {
-		return &awsConfig, nil
+	// profiles set explicitly take precedence over environment variables
+	if staticConfig.AccessKeyId == "" && staticConfig.AccessKeySecret == "" && config.Profile.IsNull() && config.SharedCredentialsFiles.IsNull() {
+		staticConfig.AccessKeyId = os.Getenv("AWS_ACCESS_KEY_ID")
+		staticConfig.AccessKeySecret = os.Getenv("AWS_SECRET_ACCESS_KEY")
+		staticConfig.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
+	}
+	staticConfig.Source = common.AwsSource{
+		AccessKeyId:     staticConfig.AccessKeyId,
+		AccessKeySecret: staticConfig.AccessKeySecret,
+		SessionToken:    staticConfig.SessionToken,
+		Profile:         config.Profile.ValueString(),
+	}
+	if config.AssumeRole != nil {
+		staticConfig.Source.RoleArn = config.AssumeRole.RoleArn.ValueString()
+		staticConfig.Source.SessionName = config.AssumeRole.SessionName.ValueString()
+		staticConfig.Source.ExternalId = config.AssumeRole.ExternalId.ValueString()
+		staticConfig.Source.Duration = config.AssumeRole.Duration.ValueString()
+	}
+	hasStaticCreds := len(staticConfig.AccessKeyId) > 0 && len(staticConfig.AccessKeySecret) > 0
+	if hasStaticCreds && config.AssumeRole == nil {
+		return &staticConfig, nil
 	}
-	awsConfig.AccessKeyId = os.Getenv("AWS_ACCESS_KEY_ID")
-	awsConfig.AccessKeySecret = os.Getenv("AWS_SECRET_ACCESS_KEY")
-	awsConfig.SessionToken = os.Getenv("AWS_SESSION_TOKEN")
-	if len(awsConfig.AccessKeyId) > 0 && len(awsConfig.AccessKeyId) > 0 {
-		return &awsConfig, nil
+
+	var opts []func(*awscfg.LoadOptions) error
+	if hasStaticCreds {
+		opts = append(opts, awscfg.WithCredentialsProvider(
+			awscreds.NewStaticCredentialsProvider(staticConfig.AccessKeyId, staticConfig.AccessKeySecret, staticConfig.SessionToken),
+		))
+	}
+	if !config.Profile.IsNull() {
+		opts = append(opts, awscfg.WithSharedConfigProfile(config.Profile.ValueString()))
+	}
+	if !config.SharedCredentialsFiles.IsNull() {
+		var files []string
+		if diags := config.SharedCredentialsFiles.ElementsAs(ctx, &files, false); diags.HasError() {
+			return nil, fmt.Errorf("invalid shared_credentials_files: %s", diags[0].Detail())
+		}
+		for i, f := range files {
+			expanded, err := homedir.Expand(f)
+			if err != nil {
+				return nil, fmt.Errorf("invalid shared_credentials_files: %s", err.Error())
+			}
+			files[i] = expanded
+		}
+		staticConfig.Source.SharedCredentialsFiles = files
+		opts = append(opts, awscfg.WithSharedCredentialsFiles(files))
 	}
 
-	defaultConfig, err := awscfg.LoadDefaultConfig(ctx)
+	opts = append(opts, awscfg.WithCredentialsCacheOptions(func(o *aws.CredentialsCacheOptions) {
+		o.ExpiryWindow = common.CredentialsExpiryWindow
+	}))
+	defaultConfig, err := awscfg.LoadDefaultConfig(ctx, opts...)
 	if err != nil {
 		return nil, fmt.Errorf("aws credentials not set, unable to retrieve default config: %s", err.Error())
 	}
-	awsCreds, err := defaultConfig.Credentials.Retrieve(ctx)
-	if err != nil {
+	credsProvider := defaultConfig.Credentials
+	if config.AssumeRole != nil {
+		credsProvider, err = getAssumeRoleProvider(defaultConfig, config.AssumeRole)
+		if err != nil {
+			return nil, err
+		}
+	}
+	awsCreds, err := credsProvider.Retrieve(ctx)
+	if err != nil && config.AssumeRole != nil {
+		return nil, fmt.Errorf("unable to assume role %s: %s", config.AssumeRole.RoleArn.ValueString(), err.Error())
+	} else if err != nil {
 		return nil, fmt.Errorf("aws credentials not set, unable to retrieve default config: %s", err.Error())
 	}
-	awsConfig.AccessKeyId = awsCreds.AccessKeyID
-	awsConfig.AccessKeySecret = awsCreds.SecretAccessKey
-	return &awsConfig, nil
+	result := &common.AwsConfig{
+		AccessKeyId:     awsCreds.AccessKeyID,
+		AccessKeySecret: awsCreds.SecretAccessKey,
+		SessionToken:    awsCreds.SessionToken,
+		Source:          staticConfig.Source,
+	}
+	if awsCreds.CanExpire {
+		tflog.Info(ctx, "Using temporary AWS credentials", map[string]interface{}{"expires": awsCreds.Expires.String()})
+		result.Provider = credsProvider
+	}
+	return result, nil
+}
+
+// getAssumeRoleProvider returns a provider of temporary credentials for the given role, using the credentials in cfg
+// to assume it.
+func getAssumeRoleProvider(cfg aws.Config, config *providerAwsAssumeRoleConfig) (aws.CredentialsProvider, error) {
+	if config.RoleArn.IsUnknown() || config.SessionName.IsUnknown() || config.ExternalId.IsUnknown() || config.Duration.IsUnknown() {
+		return nil, fmt.Errorf("cannot use unknown values in assume_role")
+	}
+	duration := stscreds.DefaultDuration
+	if !config.Duration.IsNull() {
+		d, err := time.ParseDuration(config.Duration.ValueString())
+		if err != nil || d <= 0 {
+			return nil, fmt.Errorf("assume_role duration must be a positive duration, such as 1h")
+		}
+		duration = d
+	}
+	sessionName := "multy-terraform-provider"
+	if !config.SessionName.IsNull() {
+		sessionName = config.SessionName.ValueString()
+	}
+
+	if cfg.Region == "" {
+		// STS is a global service, but the client still needs a region
+		cfg.Region = "us-east-1"
+	}
+	provider := stscreds.NewAssumeRoleProvider(sts.NewFromConfig(cfg), config.RoleArn.ValueString(), func(o *stscreds.AssumeRoleOptions) {
+		o.RoleSessionName = sessionName
+		o.Duration = duration
+		if !config.ExternalId.IsNull() {
+			o.ExternalID = aws.String(config.ExternalId.ValueString())
+		}
+	})
+	return aws.NewCredentialsCache(provider, func(o *aws.CredentialsCacheOptions) {
+		o.ExpiryWindow = common.CredentialsExpiryWindow
+	}), nil
 }
 
 func (p *Provider) validateAzureConfig(config *providerAzureConfig) (*common.AzureConfig, error) {
@@ -430,11 +995,10 @@ func (p *Provider) validateAzureConfig(config *providerAzureConfig) (*common.Azu
 		return &azureConfig, nil
 	}
 
-	// todo check if access is valid by calling sts.GetCallerIdentity
 	return &azureConfig, fmt.Errorf("azure credentials not set")
 }
 
-func (p *Provider) validateGcpConfig(config *providerGcpConfig) (*common.GcpConfig, error) {
+func (p *Provider) validateGcpConfig(ctx context.Context, config *providerGcpConfig) (*common.GcpConfig, error) {
 	var c common.GcpConfig
 
 	if config.Credentials.IsUnknown() {
@@ -443,39 +1007,127 @@ func (p *Provider) validateGcpConfig(config *providerGcpConfig) (*common.GcpConf
 	if config.Project.IsUnknown() {
 		return nil, fmt.Errorf("cannot use unknown value as project")
 	}
+	if config.ImpersonateServiceAccount.IsUnknown() || config.ImpersonateServiceAccountDelegates.IsUnknown() {
+		return nil, fmt.Errorf("cannot use unknown value as impersonate_service_account or impersonate_service_account_delegates")
+	}
+
+	creds, source, err := getGcpCredentials(config)
+	if err != nil {
+		return nil, err
+	}
+	var parsedCreds gcpCredentialsFile
+	if err := json.Unmarshal([]byte(creds), &parsedCreds); err != nil {
+		return nil, fmt.Errorf("google credentials from %s are not valid JSON: %s", source, err.Error())
+	}
+
+	impersonate := config.ImpersonateServiceAccount.ValueString()
+	if impersonate == "" {
+		impersonate = os.Getenv("GOOGLE_IMPERSONATE_SERVICE_ACCOUNT")
+	}
+	if impersonate != "" {
+		var delegates []string
+		if !config.ImpersonateServiceAccountDelegates.IsNull() {
+			if diags := config.ImpersonateServiceAccountDelegates.ElementsAs(ctx, &delegates, false); diags.HasError() {
+				return nil, fmt.Errorf("invalid impersonate_service_account_delegates: %s", diags[0].Detail())
+			}
+		}
+		creds, err = impersonatedGcpCredentials(creds, impersonate, delegates)
+		if err != nil {
+			return nil, err
+		}
+	} else if !config.ImpersonateServiceAccountDelegates.IsNull() {
+		return nil, fmt.Errorf("impersonate_service_account_delegates can only be used with impersonate_service_account")
+	}
+	c.Credentials = creds
 
 	if !config.Project.IsNull() {
 		c.Project = config.Project.ValueString()
 	} else if project, ok := os.LookupEnv("GOOGLE_PROJECT"); ok {
 		c.Project = project
+	} else if parsedCreds.ProjectId != "" {
+		c.Project = parsedCreds.ProjectId
+	} else if parsedCreds.QuotaProjectId != "" {
+		c.Project = parsedCreds.QuotaProjectId
 	} else {
-		return nil, fmt.Errorf("google project is not set")
+		return nil, fmt.Errorf("google project is not set and can't be inferred from the %s credentials from %s", parsedCreds.Type, source)
 	}
 
+	return &c, nil
+}
+
+// gcpCredentialsFile has the fields of a google credentials file that the provider uses.
+type gcpCredentialsFile struct {
+	Type           string `json:"type"`
+	ProjectId      string `json:"project_id"`
+	QuotaProjectId string `json:"quota_project_id"`
+}
+
+// getGcpCredentials returns the contents of the google credentials file and where it was found. Application default
+// credentials are used if no credentials are set explicitly, including the ones created by `gcloud auth
+// application-default login`.
+func getGcpCredentials(config *providerGcpConfig) (string, string, error) {
 	if !config.Credentials.IsNull() {
 		contents, _, err := pathOrContents(config.Credentials.ValueString())
 		if err != nil {
-			return nil, err
+			return "", "", fmt.Errorf("unable to read google credentials: %s", err.Error())
 		}
-		c.Credentials = contents
-	} else if creds, ok := os.LookupEnv("GOOGLE_CREDENTIALS"); ok {
-		c.Credentials = creds
-	} else if credsFile, ok := os.LookupEnv("GOOGLE_APPLICATION_CREDENTIALS"); ok {
+		return contents, "the provider credentials attribute", nil
+	}
+	if creds, ok := os.LookupEnv("GOOGLE_CREDENTIALS"); ok {
+		return creds, "GOOGLE_CREDENTIALS", nil
+	}
+	if credsFile, ok := os.LookupEnv("GOOGLE_APPLICATION_CREDENTIALS"); ok {
 		contents, fromFile, err := pathOrContents(credsFile)
 		if err != nil {
-			return nil, err
+			return "", "", fmt.Errorf("unable to read GOOGLE_APPLICATION_CREDENTIALS: %s", err.Error())
 		}
 		if !fromFile {
-			return nil, fmt.Errorf(
+			return "", "", fmt.Errorf(
 				"GOOGLE_APPLICATION_CREDENTIALS should contain a path to the JSON file, but the content was found" +
 					" instead. Did you mean to use GOOGLE_CREDENTIALS?")
 		}
-		c.Credentials = contents
-	} else {
-		return nil, fmt.Errorf("google credentials not set")
+		return contents, "GOOGLE_APPLICATION_CREDENTIALS", nil
 	}
 
-	return &c, nil
+	adcFile := gcloudAdcFile()
+	if contents, err := os.ReadFile(adcFile); err == nil {
+		return string(contents), adcFile, nil
+	} else if !os.IsNotExist(err) {
+		return "", "", fmt.Errorf("unable to read application default credentials from %s: %s", adcFile, err.Error())
+	}
+	return "", "", fmt.Errorf("google credentials not set. Set credentials, GOOGLE_CREDENTIALS or " +
+		"GOOGLE_APPLICATION_CREDENTIALS, or run `gcloud auth application-default login`")
+}
+
+// gcloudAdcFile returns the path where gcloud stores application default credentials.
+func gcloudAdcFile() string {
+	const name = "application_default_credentials.json"
+	if dir := os.Getenv("CLOUDSDK_CONFIG"); dir != "" {
+		return filepath.Join(dir, name)
+	}
+	if runtime.GOOS == "windows" {
+		return filepath.Join(os.Getenv("APPDATA"), "gcloud", name)
+	}
+	home, _ := homedir.Dir()
+	return filepath.Join(home, ".config", "gcloud", name)
+}
+
+// impersonatedGcpCredentials wraps creds into credentials that impersonate the given service account, optionally
+// through a chain of delegates.
+func impersonatedGcpCredentials(creds string, serviceAccount string, delegates []string) (string, error) {
+	for i, d := range delegates {
+		delegates[i] = "projects/-/serviceAccounts/" + d
+	}
+	b, err := json.Marshal(map[string]any{
+		"type":                              "impersonated_service_account",
+		"service_account_impersonation_url": fmt.Sprintf("https://iamcredentials.googleapis.com/v1/projects/-/serviceAccounts/%s:generateAccessToken", serviceAccount),
+		"delegates":                         delegates,
+		"source_credentials":                json.RawMessage(creds),
+	})
+	if err != nil {
+		return "", fmt.Errorf("unable to impersonate %s: %s", serviceAccount, err.Error())
+	}
+	return string(b), nil
 }
 
 func pathOrContents(pathOrContent string) (string, bool, error) {
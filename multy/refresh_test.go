@@ -0,0 +1,68 @@
+package multy
+
+import (
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"testing"
+)
+
+func TestRefreshIsCachedPerOperation(t *testing.T) {
+	h := newPlanHarness(t, "aws")
+	h.apply("multy_virtual_network.vn", map[string]any{"name": "vn", "cidr_block": "10.0.0.0/16", "cloud": "aws", "location": "eu_west_1"})
+	h.apply("multy_public_ip.pip", map[string]any{"name": "pip", "cloud": "aws", "location": "eu_west_1"})
+
+	h.fake.setError("RefreshState", status.Error(codes.InvalidArgument, "unable to refresh"))
+	if errs := diagnosticErrors(h.read("multy_virtual_network.vn")); errs == "" {
+		t.Fatalf("expected read to fail while the state can't be refreshed")
+	}
+	h.fake.setError("RefreshState", nil)
+
+	before := countCalls(h.fake, "RefreshState")
+	if errs := diagnosticErrors(h.read("multy_virtual_network.vn")); errs != "" {
+		t.Fatalf("failed refreshes shouldn't be cached, got %s", errs)
+	}
+	// aws, azure and gcp are refreshed once, and then reused by every read
+	if got := countCalls(h.fake, "RefreshState") - before; got != 3 {
+		t.Errorf("expected every cloud to be refreshed once, got %d refreshes", got)
+	}
+	h.read("multy_public_ip.pip")
+	h.read("multy_virtual_network.vn")
+	if got := countCalls(h.fake, "RefreshState") - before; got != 3 {
+		t.Errorf("expected refreshes to be reused by later reads, got %d refreshes", got)
+	}
+
+	// configuring the provider again starts a new operation with its own cache
+	other := newPlanHarness(t, "aws")
+	other.apply("multy_virtual_network.vn", map[string]any{"name": "vn", "cidr_block": "10.0.0.0/16", "cloud": "aws", "location": "eu_west_1"})
+	other.read("multy_virtual_network.vn")
+	if got := countCalls(other.fake, "RefreshState"); got != 3 {
+		t.Errorf("expected a new operation to refresh every cloud, got %d refreshes", got)
+	}
+}
+
+func TestSkipRefresh(t *testing.T) {
+	t.Setenv("MULTY_SKIP_REFRESH", "true")
+	h := newPlanHarness(t, "aws")
+	h.apply("multy_virtual_network.vn", map[string]any{"name": "vn", "cidr_block": "10.0.0.0/16", "cloud": "aws", "location": "eu_west_1"})
+	h.fake.setError("RefreshState", status.Error(codes.InvalidArgument, "unable to refresh"))
+
+	if errs := diagnosticErrors(h.read("multy_virtual_network.vn")); errs != "" {
+		t.Fatalf("unexpected error reading resource, %s", errs)
+	}
+	if got := countCalls(h.fake, "RefreshState"); got != 0 {
+		t.Errorf("expected no refreshes with skip_refresh, got %d", got)
+	}
+	if got := countCalls(h.fake, "ReadVirtualNetwork"); got != 1 {
+		t.Errorf("expected the resource to be read from the server, got %d reads", got)
+	}
+}
+
+func countCalls(s *fakeServer, method string) int {
+	n := 0
+	for _, call := range s.getCalls() {
+		if call == method {
+			n++
+		}
+	}
+	return n
+}
@@ -2,13 +2,19 @@ package multy
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
 	"github.com/hashicorp/terraform-plugin-log/tflog"
+	"github.com/multycloud/multy/api/proto/commonpb"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"reflect"
+	"strings"
 	"terraform-provider-multy/multy/common"
 )
 
@@ -20,9 +26,17 @@ type MultyResource[T any] struct {
 	deleteFunc func(ctx context.Context, p Provider, state T) error
 	name       string
 	schema     tfsdk.Schema
+	// timeouts overrides common.DefaultTimeouts for this resource type
+	timeouts common.Timeouts
+	// fieldNames maps the fields of the server API that are named differently in the schema to their attribute names,
+	// so that validation errors are attached to the right attribute (see common.FieldPath)
+	fieldNames map[string]string
 }
 
 func (r MultyResource[T]) Create(ctx context.Context, req resource.CreateRequest, resp *resource.CreateResponse) {
+	ctx, span := common.StartSpan(ctx, r.name+".Create")
+	defer func() { common.EndSpan(span, resp.Diagnostics) }()
+
 	if !r.p.Configured {
 		resp.Diagnostics.AddError(
 			"Provider not configured",
@@ -33,52 +47,73 @@ func (r MultyResource[T]) Create(ctx context.Context, req resource.CreateRequest
 
 	// Retrieve values from plan
 	plan := new(T)
-	diags := req.Plan.Get(ctx, plan)
+	extras, diags := r.get(ctx, req.Plan.Raw, plan)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	timeouts, err := r.getTimeouts(extras)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("timeouts"), "Invalid timeouts", err.Error())
+		return
+	}
 
 	c := r.p.Client
-	ctx, err := c.AddHeaders(ctx)
-	if err != nil {
-		resp.Diagnostics.AddError("Error encoding credentials", err.Error())
+	ctx, diags = addHeaders(ctx, c, req.Plan.Raw)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, timeouts.Create)
+	defer cancel()
 	state, err := r.createFunc(ctx, r.p, *plan)
 
-	if err != nil {
-		resp.Diagnostics.AddError("Error creating resource", common.ParseGrpcErrors(err))
+	if err != nil && common.IsTimeout(ctx) {
+		resp.Diagnostics.AddError("Timeout creating resource", common.TimeoutErrorMessage("create", r.name, timeouts.Create))
 		return
-	}
-
-	diags = resp.State.Set(ctx, state)
-	resp.Diagnostics.Append(diags...)
-	if resp.Diagnostics.HasError() {
+	} else if err != nil {
+		common.AddGrpcErrors(ctx, &resp.Diagnostics, "Error creating resource", err, r.schema, r.fieldNames, r.p.Client.Debug)
 		return
 	}
+
+	r.register(state)
+	resp.Diagnostics.Append(r.set(ctx, &resp.State.Raw, state, extras)...)
+	registerResource(c, r.name, resp.State.Raw)
 }
 
 func (r MultyResource[T]) Read(ctx context.Context, req resource.ReadRequest, resp *resource.ReadResponse) {
+	ctx, span := common.StartSpan(ctx, r.name+".Read")
+	defer func() { common.EndSpan(span, resp.Diagnostics) }()
+
 	c := r.p.Client
-	ctx, err := c.AddHeaders(ctx)
-	if err != nil {
-		resp.Diagnostics.AddError("Error encoding credentials", err.Error())
+	ctx, diags := addHeaders(ctx, c, req.State.Raw)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
 	// Get current state
 	state := new(T)
-	diags := req.State.Get(ctx, state)
+	extras, diags := r.get(ctx, req.State.Raw, state)
 	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
-
-	err = r.p.Client.RefreshCache.Refresh(ctx, r.p.Client.ApiKey, r.p.Client)
+	timeouts, err := r.getTimeouts(extras)
 	if err != nil {
-		resp.Diagnostics.AddError("Error refreshing resource", common.ParseGrpcErrors(err))
+		resp.Diagnostics.AddAttributeError(path.Root("timeouts"), "Invalid timeouts", err.Error())
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeouts.Read)
+	defer cancel()
+	err = r.p.Client.RefreshCache.Refresh(ctx, r.p.Client.ApiKey, r.p.Client, getAccount(req.State.Raw))
+	if err != nil && common.IsTimeout(ctx) {
+		resp.Diagnostics.AddError("Timeout refreshing resource", common.TimeoutErrorMessage("read", r.name, timeouts.Read))
+		return
+	} else if err != nil {
+		resp.Diagnostics.AddError("Error refreshing resource", common.ParseGrpcErrors(ctx, err, r.p.Client.Debug))
 		return
 	}
 	newState, err := r.readFunc(ctx, r.p, *state)
@@ -86,69 +121,257 @@ func (r MultyResource[T]) Read(ctx context.Context, req resource.ReadRequest, re
 		tflog.Info(ctx, "Resource doesn't exist, deleting")
 		// Remove MultyResource from state
 		resp.State.RemoveResource(ctx)
+	} else if err != nil && common.IsTimeout(ctx) {
+		resp.Diagnostics.AddError("Timeout reading resource", common.TimeoutErrorMessage("read", r.name, timeouts.Read))
+		return
 	} else if err != nil {
-		resp.Diagnostics.AddError("Error reading resource", common.ParseGrpcErrors(err))
+		common.AddGrpcErrors(ctx, &resp.Diagnostics, "Error reading resource", err, r.schema, r.fieldNames, r.p.Client.Debug)
 		return
 	} else {
-		diags = resp.State.Set(ctx, newState)
+		r.register(newState)
+		diags = r.set(ctx, &resp.State.Raw, newState, extras)
 		resp.Diagnostics.Append(diags...)
+		if !diags.HasError() {
+			registerResource(c, r.name, resp.State.Raw)
+			resp.Diagnostics.Append(driftWarning(ctx, r.fullSchema(), r.name, req.State.Raw, resp.State.Raw)...)
+		}
 	}
 }
 
 func (r MultyResource[T]) Update(ctx context.Context, req resource.UpdateRequest, resp *resource.UpdateResponse) {
+	ctx, span := common.StartSpan(ctx, r.name+".Update")
+	defer func() { common.EndSpan(span, resp.Diagnostics) }()
+
 	plan := new(T)
 	// Get plan values
-	resp.Diagnostics.Append(req.Plan.Get(ctx, plan)...)
+	extras, diags := r.get(ctx, req.Plan.Raw, plan)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	timeouts, err := r.getTimeouts(extras)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("timeouts"), "Invalid timeouts", err.Error())
+		return
+	}
+	// timeouts are only used by the provider, so changing them doesn't need the server
+	if equalExceptTimeouts(req.State.Raw, req.Plan.Raw) {
+		tflog.Info(ctx, "Only timeouts changed, not updating resource")
+		resp.State.Raw = req.Plan.Raw
+		return
+	}
 
 	c := r.p.Client
-	ctx, err := c.AddHeaders(ctx)
-	if err != nil {
-		resp.Diagnostics.AddError("Error encoding credentials", err.Error())
+	ctx, diags = addHeaders(ctx, c, req.Plan.Raw)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, timeouts.Update)
+	defer cancel()
 	state, err := r.updateFunc(ctx, r.p, *plan)
 
-	if err != nil {
-		resp.Diagnostics.AddError("Error updating resource", common.ParseGrpcErrors(err))
+	if err != nil && common.IsTimeout(ctx) {
+		resp.Diagnostics.AddError("Timeout updating resource", common.TimeoutErrorMessage("update", r.name, timeouts.Update))
 		return
-	}
-
-	resp.Diagnostics.Append(resp.State.Set(ctx, state)...)
-	if resp.Diagnostics.HasError() {
+	} else if err != nil {
+		common.AddGrpcErrors(ctx, &resp.Diagnostics, "Error updating resource", err, r.schema, r.fieldNames, r.p.Client.Debug)
 		return
 	}
+
+	r.register(state)
+	resp.Diagnostics.Append(r.set(ctx, &resp.State.Raw, state, extras)...)
+	registerResource(c, r.name, resp.State.Raw)
 }
 
 func (r MultyResource[T]) Delete(ctx context.Context, req resource.DeleteRequest, resp *resource.DeleteResponse) {
+	ctx, span := common.StartSpan(ctx, r.name+".Delete")
+	defer func() { common.EndSpan(span, resp.Diagnostics) }()
+
 	state := new(T)
-	resp.Diagnostics.Append(req.State.Get(ctx, state)...)
+	extras, diags := r.get(ctx, req.State.Raw, state)
+	resp.Diagnostics.Append(diags...)
 	if resp.Diagnostics.HasError() {
 		return
 	}
+	timeouts, err := r.getTimeouts(extras)
+	if err != nil {
+		resp.Diagnostics.AddAttributeError(path.Root("timeouts"), "Invalid timeouts", err.Error())
+		return
+	}
 
 	c := r.p.Client
-	ctx, err := c.AddHeaders(ctx)
-	if err != nil {
-		resp.Diagnostics.AddError("Error encoding credentials", err.Error())
+	ctx, diags = addHeaders(ctx, c, req.State.Raw)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
 		return
 	}
 
+	ctx, cancel := context.WithTimeout(ctx, timeouts.Delete)
+	defer cancel()
 	err = r.deleteFunc(ctx, r.p, *state)
 
 	if s, ok := status.FromError(err); ok && (s.Code() == codes.NotFound || s.Code() == codes.OK) {
 		tflog.Info(ctx, "Resource was already deleted")
 		// Remove MultyResource from state
 		resp.State.RemoveResource(ctx)
+	} else if common.IsTimeout(ctx) {
+		resp.Diagnostics.AddError("Timeout deleting resource", common.TimeoutErrorMessage("delete", r.name, timeouts.Delete))
 	} else {
-		resp.Diagnostics.AddError(
-			"Error deleting resource",
-			common.ParseGrpcErrors(err),
+		common.AddGrpcErrors(ctx, &resp.Diagnostics, "Error deleting resource", err, r.schema, r.fieldNames, r.p.Client.Debug)
+	}
+}
+
+// addHeaders adds the credentials of the cloud and account of the resource in raw to ctx, or of every cloud in the
+// account if the cloud isn't known.
+func addHeaders(ctx context.Context, c *common.ProviderConfig, raw tftypes.Value) (context.Context, diag.Diagnostics) {
+	cloud, cloudPath := resolveCloud(c, raw)
+	account := getAccount(raw)
+	diags := checkCredentials(c, cloud, cloudPath, account)
+	if diags.HasError() {
+		return ctx, diags
+	}
+	ctx, err := c.AddHeadersForCloud(ctx, cloud, account)
+	if err != nil {
+		diags.AddError("Error encoding credentials", err.Error())
+	}
+	return ctx, diags
+}
+
+// checkCredentials returns an error if the account doesn't exist or doesn't have credentials for the given cloud. An
+// empty account refers to the provider's top-level credentials, and cloudPath is the attribute the cloud comes from.
+func checkCredentials(c *common.ProviderConfig, cloud commonpb.CloudProvider, cloudPath path.Path, account string) diag.Diagnostics {
+	var diags diag.Diagnostics
+	creds, ok := c.GetAccount(account)
+	if !ok {
+		diags.AddAttributeError(
+			path.Root("account"),
+			"Unknown account",
+			fmt.Sprintf("Account %s isn't configured in the provider. Add it to the provider's aws_accounts, azure_accounts or gcp_accounts attribute.", account),
 		)
+		return diags
+	}
+	if cloud == commonpb.CloudProvider_UNKNOWN_PROVIDER || creds.HasCredentials(cloud) {
+		return diags
 	}
+	name := strings.ToLower(cloud.String())
+	if account != "" {
+		diags.AddAttributeError(
+			path.Root("account"),
+			"Missing cloud credentials",
+			fmt.Sprintf("Resource is deployed in %s, but account %s doesn't have %s credentials. Set them in the provider's %s_accounts attribute.", name, account, name, name),
+		)
+	} else {
+		diags.AddAttributeError(
+			cloudPath,
+			"Missing cloud credentials",
+			fmt.Sprintf("Resource is deployed in %s, but the provider doesn't have %s credentials. Set them in the provider's %s attribute or through environment variables.", name, name, name),
+		)
+	}
+	return diags
+}
+
+// parentAttributes reference the resource that a resource without a cloud attribute is deployed in, such as the virtual
+// network of a subnet or the vault of a vault secret.
+var parentAttributes = []string{"virtual_network_id", "route_table_id", "network_interface_id", "vault_id", "object_storage_id", "cluster_id"}
+
+// resolveCloud returns the cloud of the resource in raw and the attribute it comes from. If raw doesn't have a known
+// cloud attribute, the cloud is taken from the resource itself or its parent, if the provider has read or applied them.
+// CloudProvider_UNKNOWN_PROVIDER is returned if none of them are known.
+func resolveCloud(c *common.ProviderConfig, raw tftypes.Value) (commonpb.CloudProvider, path.Path) {
+	if cloud := getCloud(raw); cloud != commonpb.CloudProvider_UNKNOWN_PROVIDER || c.Clouds == nil {
+		return cloud, path.Root("cloud")
+	}
+	for _, attrName := range append([]string{"id"}, parentAttributes...) {
+		if id := getString(raw, attrName); id != "" {
+			if cloud, ok := c.Clouds.Get(id); ok {
+				return cloud, path.Root(attrName)
+			}
+		}
+	}
+	return commonpb.CloudProvider_UNKNOWN_PROVIDER, path.Root("cloud")
+}
+
+// registerResource records the type and cloud of the resource in raw, so that the resources referencing it can resolve
+// their cloud and data source lookups can skip it if it has another type.
+func registerResource(c *common.ProviderConfig, typeName string, raw tftypes.Value) {
+	id := getString(raw, "id")
+	if id == "" {
+		return
+	}
+	if c.Types != nil {
+		c.Types.Add(id, typeName)
+	}
+	if c.Clouds == nil {
+		return
+	}
+	if cloud, _ := resolveCloud(c, raw); cloud != commonpb.CloudProvider_UNKNOWN_PROVIDER {
+		c.Clouds.Add(id, cloud)
+	}
+}
+
+// getCloud returns the cloud in raw, or CloudProvider_UNKNOWN_PROVIDER if it doesn't have a known cloud attribute.
+func getCloud(raw tftypes.Value) commonpb.CloudProvider {
+	cloud := getString(raw, "cloud")
+	return commonpb.CloudProvider(commonpb.CloudProvider_value[strings.ToUpper(cloud)])
+}
+
+// accountRequiresReplace returns whether moving a resource from the account in prior to the one in plan requires
+// replacing it. Resources stay in place if both accounts have the same credentials for their cloud, such as when an
+// imported resource is moved to an account with the top-level credentials.
+func accountRequiresReplace(c *common.ProviderConfig, prior, plan tftypes.Value) bool {
+	priorAccount, planAccount := getAccount(prior), getAccount(plan)
+	if !isKnownAttribute(plan, "account") {
+		return true
+	}
+	if priorAccount == planAccount {
+		return false
+	}
+	if c == nil {
+		return true
+	}
+	priorCreds, ok := c.GetAccount(priorAccount)
+	if !ok {
+		return true
+	}
+	planCreds, ok := c.GetAccount(planAccount)
+	if !ok {
+		return true
+	}
+	cloud, _ := resolveCloud(c, plan)
+	return !priorCreds.Equal(planCreds, cloud)
+}
+
+// isKnownAttribute returns whether the given top-level attribute in raw is known, including when it's null.
+func isKnownAttribute(raw tftypes.Value, attrName string) bool {
+	values := map[string]tftypes.Value{}
+	if !raw.IsKnown() || raw.As(&values) != nil {
+		return false
+	}
+	return values[attrName].IsKnown()
+}
+
+// getAccount returns the account in raw, or an empty string if it doesn't have a known account attribute.
+func getAccount(raw tftypes.Value) string {
+	return getString(raw, "account")
+}
+
+// getString returns the value of the given top-level string attribute in raw, or an empty string if it isn't known.
+func getString(raw tftypes.Value, attrName string) string {
+	values := map[string]tftypes.Value{}
+	if !raw.IsKnown() || raw.IsNull() || raw.As(&values) != nil {
+		return ""
+	}
+	v, ok := values[attrName]
+	if !ok || !v.IsKnown() || v.IsNull() {
+		return ""
+	}
+	var s string
+	if err := v.As(&s); err != nil {
+		return ""
+	}
+	return s
 }
 
 func (r MultyResource[T]) Metadata(_ context.Context, _ resource.MetadataRequest, resp *resource.MetadataResponse) {
@@ -156,7 +379,97 @@ func (r MultyResource[T]) Metadata(_ context.Context, _ resource.MetadataRequest
 }
 
 func (r MultyResource[T]) GetSchema(_ context.Context) (tfsdk.Schema, diag.Diagnostics) {
-	return r.schema, nil
+	return r.fullSchema(), nil
+}
+
+// fullSchema adds the timeouts block and the account attribute to the resource schema. Resource structs don't have
+// them, so r.get and r.set need to be used instead of the Get and Set methods from the framework.
+func (r MultyResource[T]) fullSchema() tfsdk.Schema {
+	s := r.schema
+	s.Attributes = map[string]tfsdk.Attribute{"account": common.AccountSchema}
+	for name, a := range r.schema.Attributes {
+		s.Attributes[name] = a
+	}
+	s.Blocks = map[string]tfsdk.Block{"timeouts": common.TimeoutsSchema(r.timeouts)}
+	for name, block := range r.schema.Blocks {
+		s.Blocks[name] = block
+	}
+	return s
+}
+
+// isExtra returns whether the attribute or block with the given name is added by fullSchema.
+func (r MultyResource[T]) isExtra(name string) bool {
+	_, isAttribute := r.schema.Attributes[name]
+	_, isBlock := r.schema.Blocks[name]
+	return (name == "account" || name == "timeouts") && !isAttribute && !isBlock
+}
+
+// emptyExtras returns the values of the attributes and blocks added by fullSchema when they aren't set.
+func (r MultyResource[T]) emptyExtras(ctx context.Context) map[string]tftypes.Value {
+	s := r.fullSchema()
+	extras := map[string]tftypes.Value{}
+	for name, a := range s.Attributes {
+		if r.isExtra(name) {
+			extras[name] = tftypes.NewValue(a.FrameworkType().TerraformType(ctx), nil)
+		}
+	}
+	for name, b := range s.Blocks {
+		if r.isExtra(name) {
+			extras[name] = tftypes.NewValue(b.Type().TerraformType(ctx), []tftypes.Value{})
+		}
+	}
+	return extras
+}
+
+// get decodes raw into target, returning the values added by fullSchema separately.
+func (r MultyResource[T]) get(ctx context.Context, raw tftypes.Value, target any) (map[string]tftypes.Value, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	extras := r.emptyExtras(ctx)
+	withoutExtras := tftypes.NewValue(r.schema.Type().TerraformType(ctx), nil)
+	if !raw.IsNull() {
+		rawValues := map[string]tftypes.Value{}
+		if err := raw.As(&rawValues); err != nil {
+			diags.AddError("Unable to parse resource", err.Error())
+			return extras, diags
+		}
+		// rawValues shares its map with raw, so it can't be modified
+		values := map[string]tftypes.Value{}
+		for name, v := range rawValues {
+			if r.isExtra(name) {
+				extras[name] = v
+			} else {
+				values[name] = v
+			}
+		}
+		withoutExtras = tftypes.NewValue(r.schema.Type().TerraformType(ctx), values)
+	}
+
+	diags.Append(tfsdk.Plan{Schema: r.schema, Raw: withoutExtras}.Get(ctx, target)...)
+	return extras, diags
+}
+
+// set encodes value into raw, together with the values added by fullSchema.
+func (r MultyResource[T]) set(ctx context.Context, raw *tftypes.Value, value T, extras map[string]tftypes.Value) diag.Diagnostics {
+	state := tfsdk.State{Schema: r.schema}
+	diags := state.Set(ctx, value)
+	if diags.HasError() {
+		return diags
+	}
+
+	values := map[string]tftypes.Value{}
+	if err := state.Raw.As(&values); err != nil {
+		diags.AddError("Unable to encode resource", err.Error())
+		return diags
+	}
+	for name, v := range extras {
+		values[name] = v
+	}
+	*raw = tftypes.NewValue(r.fullSchema().Type().TerraformType(ctx), values)
+	return diags
+}
+
+func (r MultyResource[T]) getTimeouts(extras map[string]tftypes.Value) (common.Timeouts, error) {
+	return r.timeouts.WithDefaults().Merge(extras["timeouts"])
 }
 
 type planUpdater[T any] interface {
@@ -164,39 +477,287 @@ type planUpdater[T any] interface {
 }
 
 func (r MultyResource[T]) ModifyPlan(ctx context.Context, req resource.ModifyPlanRequest, resp *resource.ModifyPlanResponse) {
-	plan := new(T)
-	diags := req.Plan.Get(ctx, &plan)
+	if req.Plan.Raw.IsNull() {
+		tflog.Info(ctx, "Plan is empty")
+		return
+	}
+	defaults, diags := r.getProviderDefaults(req.Config.Raw)
 	resp.Diagnostics.Append(diags...)
 	if diags.HasError() {
-		tflog.Warn(ctx, "Unable to parse plan when modifying it")
 		return
 	}
+	resp.Plan.Raw, diags = withValues(req.Plan.Raw, defaults)
+	resp.Diagnostics.Append(diags...)
+	configRaw, diags := withValues(req.Config.Raw, defaults)
+	resp.Diagnostics.Append(diags...)
+	if resp.Diagnostics.HasError() {
+		return
+	}
+	resp.RequiresReplace = append(resp.RequiresReplace, changedAttributes(req.State.Raw, defaults)...)
+	if r.p.Client != nil {
+		cloud, cloudPath := resolveCloud(r.p.Client, resp.Plan.Raw)
+		resp.Diagnostics.Append(checkCredentials(r.p.Client, cloud, cloudPath, getAccount(resp.Plan.Raw))...)
+		if resp.Diagnostics.HasError() {
+			return
+		}
+	}
+	if !req.State.Raw.IsNull() && accountRequiresReplace(r.p.Client, req.State.Raw, resp.Plan.Raw) {
+		resp.RequiresReplace = append(resp.RequiresReplace, path.Root("account"))
+	}
+	if !req.State.Raw.IsNull() {
+		if planned, ok := keepStateForTimeouts(req.State.Raw, configRaw, resp.Plan.Raw); ok {
+			resp.Plan.Raw = planned
+			return
+		}
+	}
 
-	if plan == nil {
-		tflog.Info(ctx, "Plan is empty")
+	plan := new(T)
+	extras, diags := r.get(ctx, resp.Plan.Raw, &plan)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		tflog.Warn(ctx, "Unable to parse plan when modifying it")
 		return
 	}
 
 	config := new(T)
-	diags = req.Config.Get(ctx, &config)
+	_, diags = r.get(ctx, configRaw, &config)
 	resp.Diagnostics.Append(diags...)
 	if diags.HasError() {
 		tflog.Warn(ctx, "Unable to parse config when modifying it")
 		return
 	}
 
+	newPlan := *plan
 	if c, ok := (any(*plan)).(planUpdater[T]); ok {
 		tflog.Info(ctx, "Updating plan")
-		newPlan, requiresReplace := c.UpdatePlan(ctx, *config, r.p)
-		resp.Plan.Set(ctx, newPlan)
-		resp.RequiresReplace = requiresReplace
+		var requiresReplace []path.Path
+		newPlan, requiresReplace = c.UpdatePlan(ctx, *config, r.p)
+		resp.Diagnostics.Append(r.set(ctx, &resp.Plan.Raw, newPlan, extras)...)
+		resp.RequiresReplace = append(resp.RequiresReplace, requiresReplace...)
 	} else {
 		tflog.Info(ctx, "Not updating plan because it doesn't implement planUpdater")
 	}
 
+	if r.p.Client == nil {
+		return
+	}
+	if v, ok := (any(newPlan)).(planValidator[T]); ok {
+		var priorState *T
+		if !req.State.Raw.IsNull() {
+			priorState = new(T)
+			_, diags = r.get(ctx, req.State.Raw, priorState)
+			resp.Diagnostics.Append(diags...)
+			if diags.HasError() {
+				return
+			}
+		}
+		resp.Diagnostics.Append(v.ValidatePlan(ctx, priorState, r.p.Client.Networks)...)
+	}
+}
+
+// keepStateForTimeouts returns the prior state with the planned timeouts if nothing else changes in plan. The framework
+// marks computed attributes as unknown whenever the plan differs from the state, but a timeouts change never reaches the
+// server, so they keep their prior values.
+func keepStateForTimeouts(prior, config, plan tftypes.Value) (tftypes.Value, bool) {
+	planned, err := tftypes.Transform(plan, func(p *tftypes.AttributePath, v tftypes.Value) (tftypes.Value, error) {
+		if v.IsKnown() || len(p.Steps()) == 0 {
+			return v, nil
+		}
+		// only computed attributes that aren't set in config are replaced, values that depend on other resources stay
+		// unknown
+		if c, _, err := tftypes.WalkAttributePath(config, p); err != nil || !c.(tftypes.Value).IsNull() {
+			return v, nil
+		}
+		if s, _, err := tftypes.WalkAttributePath(prior, p); err == nil {
+			return s.(tftypes.Value), nil
+		}
+		return v, nil
+	})
+	if err != nil || !equalExceptTimeouts(prior, planned) {
+		return plan, false
+	}
+	return planned, true
+}
+
+// equalExceptTimeouts returns true if a and b only differ in their timeouts.
+func equalExceptTimeouts(a, b tftypes.Value) bool {
+	aValues := map[string]tftypes.Value{}
+	bValues := map[string]tftypes.Value{}
+	if a.IsNull() || b.IsNull() || a.As(&aValues) != nil || b.As(&bValues) != nil {
+		return false
+	}
+	for name, v := range bValues {
+		if name != "timeouts" && !v.Equal(aValues[name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// planValidator is implemented by resources that reference other resources, so that mistakes in those references are
+// reported when planning instead of by the server when applying. priorState is nil if the resource doesn't exist yet.
+// Implementations must not change networks, which only holds the resources that were read or applied.
+type planValidator[T any] interface {
+	ValidatePlan(ctx context.Context, priorState *T, networks *common.NetworkRegistry) diag.Diagnostics
+}
+
+// networkRegistrant is implemented by the resources that planValidator implementations check references against.
+type networkRegistrant interface {
+	register(networks *common.NetworkRegistry)
+}
+
+// register records value in the provider's network registry, if it's a network or a subnet.
+func (r MultyResource[T]) register(value T) {
+	if n, ok := (any(value)).(networkRegistrant); ok && r.p.Client != nil && r.p.Client.Networks != nil {
+		n.register(r.p.Client.Networks)
+	}
+}
+
+// getProviderDefaults returns the values for the cloud and location attributes that aren't set in the config, taken
+// from the provider's default_cloud and default_location.
+func (r MultyResource[T]) getProviderDefaults(config tftypes.Value) (map[string]tftypes.Value, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	defaults := map[string]tftypes.Value{}
+	if r.p.Client == nil {
+		// provider isn't configured yet, so there are no defaults
+		return defaults, diags
+	}
+
+	configValues := map[string]tftypes.Value{}
+	if err := config.As(&configValues); err != nil {
+		diags.AddError("Unable to parse config", err.Error())
+		return nil, diags
+	}
+
+	for _, d := range []struct{ attrName, value string }{
+		{attrName: "cloud", value: r.p.Client.DefaultCloud},
+		{attrName: "location", value: r.p.Client.DefaultLocation},
+	} {
+		if v, ok := configValues[d.attrName]; !ok || !v.IsNull() {
+			continue
+		}
+		if d.value == "" {
+			diags.AddAttributeError(
+				path.Root(d.attrName),
+				fmt.Sprintf("Missing %s", d.attrName),
+				fmt.Sprintf("%s must be set either in the resource or through the provider's default_%s attribute.", d.attrName, d.attrName),
+			)
+			continue
+		}
+		defaults[d.attrName] = tftypes.NewValue(tftypes.String, d.value)
+	}
+	return defaults, diags
+}
+
+// withValues returns a copy of raw with the given top-level attributes replaced.
+func withValues(raw tftypes.Value, values map[string]tftypes.Value) (tftypes.Value, diag.Diagnostics) {
+	var diags diag.Diagnostics
+	if len(values) == 0 || raw.IsNull() {
+		return raw, diags
+	}
+	rawValues := map[string]tftypes.Value{}
+	if err := raw.As(&rawValues); err != nil {
+		diags.AddError("Unable to parse resource", err.Error())
+		return raw, diags
+	}
+	result := map[string]tftypes.Value{}
+	for name, v := range rawValues {
+		result[name] = v
+	}
+	for name, v := range values {
+		result[name] = v
+	}
+	return tftypes.NewValue(raw.Type(), result), diags
+}
+
+// changedAttributes returns the paths of the given values that are different from the ones in the prior state. These
+// need to be replaced explicitly, since the attribute plan modifiers don't know about provider defaults.
+func changedAttributes(priorState tftypes.Value, values map[string]tftypes.Value) path.Paths {
+	var result path.Paths
+	stateValues := map[string]tftypes.Value{}
+	if priorState.IsNull() || priorState.As(&stateValues) != nil {
+		return nil
+	}
+	for name, v := range values {
+		if !v.Equal(stateValues[name]) {
+			result = append(result, path.Root(name))
+		}
+	}
+	return result
 }
 
 func (r MultyResource[T]) ImportState(ctx context.Context, req resource.ImportStateRequest, resp *resource.ImportStateResponse) {
-	// Save the import identifier in the id attribute
-	//resource.ResourceImportStatePassthroughID(ctx, tftypes.NewAttributePath().WithAttributeName("id"), req, resp)
+	ctx, span := common.StartSpan(ctx, r.name+".ImportState")
+	defer func() { common.EndSpan(span, resp.Diagnostics) }()
+
+	if !r.p.Configured {
+		resp.Diagnostics.AddError(
+			"Provider not configured",
+			"The provider hasn't been configured before import, likely because it depends on an unknown value from another resource.",
+		)
+		return
+	}
+
+	c := r.p.Client
+	account, id := parseImportId(req.ID)
+	if diags := checkCredentials(c, commonpb.CloudProvider_UNKNOWN_PROVIDER, path.Root("cloud"), account); diags.HasError() {
+		resp.Diagnostics.Append(diags...)
+		return
+	}
+	ctx, err := c.AddHeadersForCloud(ctx, commonpb.CloudProvider_UNKNOWN_PROVIDER, account)
+	if err != nil {
+		resp.Diagnostics.AddError("Error encoding credentials", err.Error())
+		return
+	}
+
+	timeouts := r.timeouts.WithDefaults()
+	ctx, cancel := context.WithTimeout(ctx, timeouts.Read)
+	defer cancel()
+	err = c.RefreshCache.Refresh(ctx, c.ApiKey, c, account)
+	if err != nil && common.IsTimeout(ctx) {
+		resp.Diagnostics.AddError("Timeout refreshing resource", common.TimeoutErrorMessage("read", r.name, timeouts.Read))
+		return
+	} else if err != nil {
+		resp.Diagnostics.AddError("Error refreshing resource", common.ParseGrpcErrors(ctx, err, r.p.Client.Debug))
+		return
+	}
+
+	// readFunc only needs the id, every other attribute (cloud, location, overrides...) comes from the server
+	state, err := r.readFunc(ctx, r.p, newWithId[T](id))
+	if s, ok := status.FromError(err); ok && s.Code() == codes.NotFound {
+		resp.Diagnostics.AddError(
+			"Cannot import non-existent remote object",
+			fmt.Sprintf("Resource with id %s doesn't exist.", id),
+		)
+		return
+	} else if err != nil && common.IsTimeout(ctx) {
+		resp.Diagnostics.AddError("Timeout importing resource", common.TimeoutErrorMessage("read", r.name, timeouts.Read))
+		return
+	} else if err != nil {
+		common.AddGrpcErrors(ctx, &resp.Diagnostics, "Error importing resource", err, r.schema, r.fieldNames, r.p.Client.Debug)
+		return
+	}
+
+	extras := r.emptyExtras(ctx)
+	if account != "" {
+		extras["account"] = tftypes.NewValue(tftypes.String, account)
+	}
+	resp.Diagnostics.Append(r.set(ctx, &resp.State.Raw, state, extras)...)
+	registerResource(c, r.name, resp.State.Raw)
+}
+
+// parseImportId splits an import id of the form <account>/<id> into the account and the resource id. Ids without an
+// account are imported with the provider's top-level credentials.
+func parseImportId(importId string) (string, string) {
+	if account, id, ok := strings.Cut(importId, "/"); ok && account != "" && id != "" {
+		return account, id
+	}
+	return "", importId
+}
+
+// newWithId returns an empty T with only its Id attribute set.
+func newWithId[T any](id string) T {
+	t := new(T)
+	reflect.ValueOf(t).Elem().FieldByName("Id").Set(reflect.ValueOf(types.StringValue(id)))
+	return *t
 }
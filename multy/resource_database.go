@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -14,6 +15,7 @@ import (
 	"terraform-provider-multy/multy/common"
 	"terraform-provider-multy/multy/mtypes"
 	"terraform-provider-multy/multy/validators"
+	"time"
 )
 
 type ResourceDatabaseType struct{}
@@ -149,6 +151,8 @@ func (r ResourceDatabaseType) NewResource(_ context.Context, p provider.Provider
 		deleteFunc: deleteDatabase,
 		name:       "multy_database",
 		schema:     databaseSchema,
+		timeouts:   common.Timeouts{Create: time.Hour, Update: time.Hour, Delete: 30 * time.Minute},
+		fieldNames: map[string]string{"gcp_override": "gcp_overrides"},
 	}
 }
 
@@ -212,6 +216,10 @@ type Database struct {
 	ResourceStatus     types.Map                                    `tfsdk:"resource_status"`
 }
 
+func (v Database) ValidatePlan(_ context.Context, _ *Database, networks *common.NetworkRegistry) diag.Diagnostics {
+	return validateSubnetReference(networks, v.Cloud, v.Location, v.SubnetId)
+}
+
 func convertToDatabase(res *resourcespb.DatabaseResource) Database {
 	return Database{
 		Id:                 types.StringValue(res.CommonParameters.ResourceId),
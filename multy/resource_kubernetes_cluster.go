@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -13,6 +14,7 @@ import (
 	"github.com/multycloud/multy/api/proto/resourcespb"
 	"terraform-provider-multy/multy/common"
 	"terraform-provider-multy/multy/mtypes"
+	"time"
 )
 
 type ResourceKubernetesClusterType struct{}
@@ -130,6 +132,13 @@ func (r ResourceKubernetesClusterType) NewResource(_ context.Context, p provider
 		deleteFunc: deleteKubernetesCluster,
 		name:       "multy_kubernetes_cluster",
 		schema:     kubernetesClusterSchema,
+		timeouts:   common.Timeouts{Create: time.Hour, Update: time.Hour, Delete: time.Hour},
+		fieldNames: map[string]string{
+			"default_node_pool.aws_override":      "aws_overrides",
+			"default_node_pool.azure_override":    "azure_overrides",
+			"default_node_pool.availability_zone": "availability_zones",
+			"gcp_override":                        "gcp_overrides",
+		},
 	}
 }
 
@@ -194,6 +203,10 @@ type KubernetesCluster struct {
 	ResourceStatus types.Map    `tfsdk:"resource_status"`
 }
 
+func (v KubernetesCluster) ValidatePlan(_ context.Context, _ *KubernetesCluster, networks *common.NetworkRegistry) diag.Diagnostics {
+	return validateVirtualNetworkReference(networks, v.Cloud, v.Location, v.VirtualNetworkId)
+}
+
 func convertToKubernetesCluster(res *resourcespb.KubernetesClusterResource) KubernetesCluster {
 	return KubernetesCluster{
 		Id:                 types.StringValue(res.CommonParameters.ResourceId),
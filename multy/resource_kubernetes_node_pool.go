@@ -13,6 +13,7 @@ import (
 	"terraform-provider-multy/multy/common"
 	"terraform-provider-multy/multy/mtypes"
 	"terraform-provider-multy/multy/validators"
+	"time"
 )
 
 type ResourceKubernetesNodePoolType struct{}
@@ -166,6 +167,8 @@ func (r ResourceKubernetesNodePoolType) NewResource(_ context.Context, p provide
 			MarkdownDescription: "Provides Multy Object Storage Object resource",
 			Attributes:          attrs,
 		},
+		timeouts:   common.Timeouts{Create: 45 * time.Minute, Update: 45 * time.Minute, Delete: 45 * time.Minute},
+		fieldNames: map[string]string{"aws_override": "aws_overrides", "azure_override": "azure_overrides", "availability_zone": "availability_zones"},
 	}
 }
 
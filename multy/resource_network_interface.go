@@ -3,6 +3,7 @@ package multy
 import (
 	"context"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
@@ -143,6 +144,10 @@ type NetworkInterface struct {
 	ResourceStatus   types.Map                                `tfsdk:"resource_status"`
 }
 
+func (v NetworkInterface) ValidatePlan(_ context.Context, _ *NetworkInterface, networks *common.NetworkRegistry) diag.Diagnostics {
+	return validateSubnetReference(networks, v.Cloud, v.Location, v.SubnetId)
+}
+
 func convertToNetworkInterface(res *resourcespb.NetworkInterfaceResource) NetworkInterface {
 	return NetworkInterface{
 		Id:               types.StringValue(res.CommonParameters.ResourceId),
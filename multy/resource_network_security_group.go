@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -148,6 +149,13 @@ func (r ResourceNetworkSecurityGroupType) NewResource(_ context.Context, p provi
 		deleteFunc: deleteNetworkSecurityGroup,
 		name:       "multy_network_security_group",
 		schema:     nsgSchema,
+		fieldNames: map[string]string{
+			"rules":                 "rule",
+			"rules.port_range":      "",
+			"rules.port_range.from": "from_port",
+			"rules.port_range.to":   "to_port",
+			"gcp_override":          "gcp_overrides",
+		},
 	}
 }
 
@@ -214,6 +222,10 @@ type Rule struct {
 	Direction types.String `tfsdk:"direction"`
 }
 
+func (v NetworkSecurityGroup) ValidatePlan(_ context.Context, _ *NetworkSecurityGroup, networks *common.NetworkRegistry) diag.Diagnostics {
+	return validateVirtualNetworkReference(networks, v.Cloud, v.Location, v.VirtualNetworkId)
+}
+
 func convertToNetworkSecurityGroup(res *resourcespb.NetworkSecurityGroupResource) NetworkSecurityGroup {
 	var rules []Rule
 	for _, rule := range res.Rules {
@@ -101,6 +101,7 @@ func (r ResourceObjectStorageType) NewResource(_ context.Context, p provider.Pro
 		deleteFunc: deleteObjectStorage,
 		name:       "multy_object_storage",
 		schema:     objectStorageSchema,
+		fieldNames: map[string]string{"gcp_override": "gcp_overrides"},
 	}
 }
 
@@ -93,6 +93,7 @@ func (r ResourcePublicIpType) NewResource(_ context.Context, p provider.Provider
 		deleteFunc: deletePublicIp,
 		name:       "multy_public_ip",
 		schema:     publicIpSchema,
+		fieldNames: map[string]string{"gcp_override": "gcp_overrides"},
 	}
 }
 
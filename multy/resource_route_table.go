@@ -97,6 +97,7 @@ func (r ResourceRouteTableType) NewResource(_ context.Context, p provider.Provid
 		deleteFunc: deleteRouteTable,
 		name:       "multy_route_table",
 		schema:     routeTableSchema,
+		fieldNames: map[string]string{"routes": "route"},
 	}
 }
 
@@ -2,7 +2,10 @@ package multy
 
 import (
 	"context"
+	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
+	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
@@ -135,6 +138,54 @@ type Subnet struct {
 	ResourceStatus   types.Map    `tfsdk:"resource_status"`
 }
 
+func (v Subnet) info() common.SubnetInfo {
+	return common.SubnetInfo{
+		Id:               knownString(v.Id),
+		Name:             knownString(v.Name),
+		CidrBlock:        knownString(v.CidrBlock),
+		VirtualNetworkId: knownString(v.VirtualNetworkId),
+	}
+}
+
+func (v Subnet) register(networks *common.NetworkRegistry) {
+	if info := v.info(); info.Id != "" && info.VirtualNetworkId != "" {
+		networks.AddSubnet(info)
+	}
+}
+
+// ValidatePlan warns about CIDR blocks that conflict with the virtual network and subnets the provider last read.
+// Terraform plans each resource on its own, so these can be changed or destroyed in the same plan, for example when
+// subnets swap CIDR blocks or the virtual network is widened, and conflicts can't be reported as errors.
+func (v Subnet) ValidatePlan(_ context.Context, priorState *Subnet, networks *common.NetworkRegistry) diag.Diagnostics {
+	var diags diag.Diagnostics
+	info := v.info()
+	if info.VirtualNetworkId == "" || info.CidrBlock == "" {
+		return diags
+	}
+	if priorState != nil && info.Id == "" {
+		// the subnet is being replaced, so it can't overlap with the subnet it replaces
+		info.Id = knownString(priorState.Id)
+	}
+
+	if vn, ok := networks.GetNetwork(info.VirtualNetworkId); ok && vn.CidrBlock != "" && !common.CidrContains(vn.CidrBlock, info.CidrBlock) {
+		diags.AddAttributeWarning(
+			path.Root("cidr_block"),
+			"Subnet outside of virtual network",
+			fmt.Sprintf("CIDR block %s is not inside the CIDR block %s of virtual network %s. Applying will fail unless "+
+				"the virtual network is changed to include it in the same plan.", info.CidrBlock, vn.CidrBlock, vn.Id),
+		)
+	}
+	for _, other := range networks.GetOverlappingSubnets(info) {
+		diags.AddAttributeWarning(
+			path.Root("cidr_block"),
+			"Overlapping subnets",
+			fmt.Sprintf("CIDR block %s overlaps with the CIDR block %s of subnet %s in the same virtual network. Applying "+
+				"will fail unless that subnet is changed or destroyed in the same plan.", info.CidrBlock, other.CidrBlock, other.Id),
+		)
+	}
+	return diags
+}
+
 func convertToSubnet(res *resourcespb.SubnetResource) Subnet {
 	result := Subnet{
 		Id:               types.StringValue(res.CommonParameters.ResourceId),
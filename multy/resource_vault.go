@@ -75,6 +75,7 @@ func (r ResourceVaultType) NewResource(_ context.Context, p provider.Provider) r
 		deleteFunc: deleteVault,
 		name:       "multy_vault",
 		schema:     vaultSchema,
+		fieldNames: map[string]string{"gcp_override": "gcp_overrides"},
 	}
 }
 
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/hashicorp/terraform-plugin-framework/attr"
+	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/path"
 	"github.com/hashicorp/terraform-plugin-framework/provider"
 	"github.com/hashicorp/terraform-plugin-framework/resource"
@@ -15,6 +16,7 @@ import (
 	"terraform-provider-multy/multy/common"
 	"terraform-provider-multy/multy/mtypes"
 	"terraform-provider-multy/multy/validators"
+	"time"
 )
 
 type ResourceVirtualMachineType struct{}
@@ -92,7 +94,7 @@ var virtualMachineSchema = tfsdk.Schema{
 			Description:   "If true, a public IP will be automatically generated. Cannot be used with `public_ip_id`",
 			Optional:      true,
 			Computed:      true,
-			PlanModifiers: []tfsdk.AttributePlanModifier{common.RequiresReplaceIfCloudEq("aws")},
+			PlanModifiers: []tfsdk.AttributePlanModifier{common.RequiresReplaceIfCloudEq("aws"), resource.UseStateForUnknown()},
 		},
 		"image_reference": {
 			Description: "Virtual Machine image definition",
@@ -184,6 +186,8 @@ func (r ResourceVirtualMachineType) NewResource(_ context.Context, p provider.Pr
 		deleteFunc: deleteVirtualMachine,
 		name:       "multy_virtual_machine",
 		schema:     virtualMachineSchema,
+		timeouts:   common.Timeouts{Create: 20 * time.Minute, Update: 20 * time.Minute, Delete: 20 * time.Minute},
+		fieldNames: map[string]string{"vm_size": "size", "aws_override": "aws_overrides", "azure_override": "azure_overrides", "gcp_override": "gcp_overrides"},
 	}
 }
 
@@ -245,6 +249,10 @@ func deleteVirtualMachine(ctx context.Context, p Provider, state VirtualMachine)
 	return err
 }
 
+func (v VirtualMachine) ValidatePlan(_ context.Context, _ *VirtualMachine, networks *common.NetworkRegistry) diag.Diagnostics {
+	return validateSubnetReference(networks, v.Cloud, v.Location, v.SubnetId)
+}
+
 func convertToVirtualMachine(res *resourcespb.VirtualMachineResource) VirtualMachine {
 	return VirtualMachine{
 		Id:                      types.StringValue(res.CommonParameters.ResourceId),
@@ -105,6 +105,7 @@ func (r ResourceVirtualNetworkType) NewResource(_ context.Context, p provider.Pr
 		deleteFunc: deleteVirtualNetwork,
 		name:       "multy_virtual_network",
 		schema:     virtualNetworkSchema,
+		fieldNames: map[string]string{"gcp_override": "gcp_overrides"},
 	}
 }
 
@@ -181,6 +182,18 @@ func (v VirtualNetwork) UpdatePlan(_ context.Context, config VirtualNetwork, p P
 	return v, requiresReplace
 }
 
+func (v VirtualNetwork) register(networks *common.NetworkRegistry) {
+	if !isKnown(v.Id) {
+		return
+	}
+	networks.AddNetwork(common.NetworkInfo{
+		Id:        v.Id.ValueString(),
+		CidrBlock: knownString(v.CidrBlock),
+		Cloud:     enumString(v.Cloud),
+		Location:  enumString(v.Location),
+	})
+}
+
 func convertToVirtualNetwork(res *resourcespb.VirtualNetworkResource) VirtualNetwork {
 	return VirtualNetwork{
 		Id:                 types.StringValue(res.CommonParameters.ResourceId),
@@ -2,6 +2,8 @@ package multy
 
 import (
 	"fmt"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
 	"github.com/hashicorp/terraform-plugin-framework/providerserver"
 	"github.com/hashicorp/terraform-plugin-go/tfprotov6"
 	"github.com/hashicorp/terraform-plugin-sdk/v2/helper/resource"
@@ -64,41 +66,68 @@ func getTestFunc(path string, testString string, testNumber int) func(t *testing
 			}
 		}
 		isError := strings.HasSuffix(filepath.Base(filepath.Dir(path)), "_failed")
+		p := &Provider{}
+		steps := []resource.TestStep{getStep(testString+getProviderBlock(testNumber), isError)}
+
+		secretPrefix, useRealServer := os.LookupEnv("USER_SECRET_PREFIX")
+		if useRealServer {
+			t.Cleanup(func() {
+				err := os.RemoveAll(filepath.Join(os.TempDir(), "multy", fmt.Sprintf("%s-%d", secretPrefix, testNumber)))
+				if err != nil {
+					t.Logf("unable to cleanup: %s", err)
+				}
+			})
+		} else {
+			p.dialer = newFakeServer(t).dial
+			if !isError {
+				steps = append(steps, getImportSteps(t, path, testString)...)
+			}
+		}
+
 		resource.ParallelTest(t, resource.TestCase{
 			ProtoV6ProviderFactories: map[string]func() (tfprotov6.ProviderServer, error){
-				"multy": providerserver.NewProtocol6WithError(&Provider{}),
+				"multy": providerserver.NewProtocol6WithError(p),
 			},
-			Steps: []resource.TestStep{getStep(testString, isError, testNumber)},
-		})
-
-		t.Cleanup(func() {
-			err := os.RemoveAll(filepath.Join(os.TempDir(), "multy", fmt.Sprintf("%s-%d", os.Getenv("USER_SECRET_PREFIX"), testNumber)))
-			if err != nil {
-				t.Logf("unable to cleanup: %s", err)
-			}
+			Steps: steps,
 		})
 	}
 }
 
+// getProviderBlock returns the provider config for the test with the given number. Tests run against a real multy
+// server on localhost:8000 if USER_SECRET_PREFIX is set, and against an in-memory fake server otherwise.
 func getProviderBlock(n int) string {
 	secretPrefix, exists := os.LookupEnv("USER_SECRET_PREFIX")
 	if !exists {
-		panic("env var USER_SECRET_PREFIX is not set")
+		return fmt.Sprintf(`
+provider "multy" {
+  aws             = { access_key_id = "fake", access_key_secret = "fake" }
+  azure           = { subscription_id = "fake", client_id = "fake", client_secret = "fake", tenant_id = "fake" }
+  gcp             = { credentials = jsonencode({ type = "service_account" }), project = "multy-project" }
+  aws_accounts    = { other = { access_key_id = "fake-other", access_key_secret = "fake-other" } }
+  azure_accounts  = { other = { subscription_id = "fake", client_id = "fake-other", client_secret = "fake-other", tenant_id = "fake" } }
+  gcp_accounts    = { other = { credentials = jsonencode({ type = "service_account" }), project = "multy-other-project" } }
+  server_endpoint = "fake"
+  api_key         = "fake-%d"
+}
+`, n)
 	}
 	return fmt.Sprintf(`
 provider "multy" {
   aws             = {}
   azure           = {}
   gcp             = { project = "multy-project" }
+  aws_accounts    = { other = {} }
+  azure_accounts  = { other = {} }
+  gcp_accounts    = { other = { project = "multy-project" } }
   server_endpoint = "localhost:8000"
   api_key = "%s-%d"
 }
 `, secretPrefix, n)
 }
 
-func getStep(config string, isError bool, n int) resource.TestStep {
+func getStep(config string, isError bool) resource.TestStep {
 	step := resource.TestStep{
-		Config: config + getProviderBlock(n),
+		Config: config,
 	}
 
 	if isError {
@@ -114,3 +143,43 @@ func getStep(config string, isError bool, n int) resource.TestStep {
 
 	return step
 }
+
+// importVerifyIgnore are the attributes that only exist in config, so imported resources never have them.
+var importVerifyIgnore = []string{"timeouts"}
+
+// getImportSteps imports every resource in config and checks that it matches the one that was created. Resources with
+// an account are imported with an <account>/<id> import id.
+func getImportSteps(t *testing.T, path string, config string) []resource.TestStep {
+	file, diags := hclsyntax.ParseConfig([]byte(config), path, hcl.InitialPos)
+	if diags.HasErrors() {
+		t.Fatalf("unable to parse %s, %s", path, diags.Error())
+	}
+	var steps []resource.TestStep
+	for _, block := range file.Body.(*hclsyntax.Body).Blocks {
+		if block.Type != "resource" || len(block.Labels) != 2 {
+			continue
+		}
+		resourceName := fmt.Sprintf("%s.%s", block.Labels[0], block.Labels[1])
+		steps = append(steps, resource.TestStep{
+			ResourceName:            resourceName,
+			ImportState:             true,
+			ImportStateIdFunc:       importStateId(resourceName),
+			ImportStateVerify:       true,
+			ImportStateVerifyIgnore: importVerifyIgnore,
+		})
+	}
+	return steps
+}
+
+func importStateId(resourceName string) resource.ImportStateIdFunc {
+	return func(state *terraform.State) (string, error) {
+		rs, ok := state.RootModule().Resources[resourceName]
+		if !ok {
+			return "", fmt.Errorf("resource %s not found in state", resourceName)
+		}
+		if account := rs.Primary.Attributes["account"]; account != "" {
+			return account + "/" + rs.Primary.ID, nil
+		}
+		return rs.Primary.ID, nil
+	}
+}
@@ -0,0 +1,41 @@
+package multy
+
+import (
+	"github.com/hashicorp/terraform-plugin-go/tftypes"
+	"strings"
+	"testing"
+)
+
+func TestTimeoutsOnlyChange(t *testing.T) {
+	h := newPlanHarness(t, "aws")
+	config := map[string]any{"name": "vn", "cidr_block": "10.0.0.0/16", "cloud": "aws", "location": "eu_west_1"}
+	h.apply("multy_virtual_network.vn", config)
+	prior := h.states["multy_virtual_network.vn"]
+
+	before := len(h.fake.getCalls())
+	planned := h.update("multy_virtual_network.vn", withEdit(config, map[string]any{"timeouts": []any{map[string]any{"create": "1h"}}}))
+
+	if !planned.IsFullyKnown() {
+		t.Errorf("expected computed attributes to keep their values, got %s", planned)
+	}
+	if !equalExceptTimeouts(prior, planned) {
+		t.Errorf("expected only timeouts to change, got %s", planned)
+	}
+	if !equalExceptTimeouts(prior, h.states["multy_virtual_network.vn"]) {
+		t.Errorf("expected only timeouts to change, got %s", h.states["multy_virtual_network.vn"])
+	}
+	for _, method := range h.fake.getCalls()[before:] {
+		if strings.HasPrefix(method, "Update") {
+			t.Errorf("expected no calls to update the resource, got %s", method)
+		}
+	}
+
+	var timeouts []tftypes.Value
+	values := map[string]tftypes.Value{}
+	if err := h.states["multy_virtual_network.vn"].As(&values); err != nil {
+		t.Fatalf("unable to decode state, %s", err)
+	}
+	if err := values["timeouts"].As(&timeouts); err != nil || len(timeouts) != 1 {
+		t.Errorf("expected timeouts to be saved in state, got %s", values["timeouts"])
+	}
+}
@@ -0,0 +1,95 @@
+package multy
+
+import (
+	"context"
+	"github.com/hashicorp/terraform-plugin-framework/provider"
+	"github.com/multycloud/multy/api/proto"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/trace"
+	collectortrace "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/metadata"
+	"net"
+	"sync"
+	"terraform-provider-multy/multy/common"
+	"testing"
+)
+
+// fakeCollector is an OTLP trace receiver that keeps every span it receives.
+type fakeCollector struct {
+	collectortrace.UnimplementedTraceServiceServer
+
+	mu    sync.Mutex
+	spans []*tracepb.Span
+}
+
+func (c *fakeCollector) Export(_ context.Context, req *collectortrace.ExportTraceServiceRequest) (*collectortrace.ExportTraceServiceResponse, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for _, rs := range req.ResourceSpans {
+		for _, ss := range rs.ScopeSpans {
+			c.spans = append(c.spans, ss.Spans...)
+		}
+	}
+	return &collectortrace.ExportTraceServiceResponse{}, nil
+}
+
+func (c *fakeCollector) spanNames() []string {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var names []string
+	for _, s := range c.spans {
+		names = append(names, s.Name)
+	}
+	return names
+}
+
+func TestServerCallsAreTraced(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("unable to listen, %s", err)
+	}
+	collector := &fakeCollector{}
+	server := grpc.NewServer()
+	collectortrace.RegisterTraceServiceServer(server, collector)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+
+	t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://"+listener.Addr().String())
+	t.Setenv("OTEL_EXPORTER_OTLP_INSECURE", "true")
+	ctx := context.Background()
+	shutdown, err := common.InitTracing(ctx)
+	if err != nil {
+		t.Fatalf("unable to initialize tracing, %s", err)
+	}
+	t.Cleanup(func() {
+		otel.SetTracerProvider(trace.NewNoopTracerProvider())
+	})
+
+	fake := newFakeServer(t)
+	resp := &provider.ConfigureResponse{}
+	client := dial("fake", common.RetryConfig{}, resp, grpc.WithContextDialer(fake.dial), grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if resp.Diagnostics.HasError() {
+		t.Fatalf("unable to dial, %s", resp.Diagnostics[0].Detail())
+	}
+	callCtx := metadata.AppendToOutgoingContext(ctx, "api_key", "fake")
+	if _, err := client.ListResources(callCtx, &proto.ListResourcesRequest{}); err != nil {
+		t.Fatalf("unable to list resources, %s", err)
+	}
+
+	// shutting down flushes the spans to the collector
+	if err := shutdown(ctx); err != nil {
+		t.Fatalf("unable to export spans, %s", err)
+	}
+	names := collector.spanNames()
+	for _, name := range names {
+		if name == "dev.multy.MultyResourceService/ListResources" {
+			return
+		}
+	}
+	t.Errorf("expected a span for ListResources, got %v", names)
+}
@@ -0,0 +1,43 @@
+package validators
+
+import (
+	"context"
+	"fmt"
+	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
+	"github.com/hashicorp/terraform-plugin-framework/types"
+	"time"
+)
+
+type IsDurationValidator struct {
+}
+
+func (v IsDurationValidator) Description(_ context.Context) string {
+	return "string value must be a valid duration, such as 30s, 10m or 1h"
+}
+
+func (v IsDurationValidator) MarkdownDescription(_ context.Context) string {
+	return "string value must be a valid duration, such as `30s`, `10m` or `1h`"
+}
+
+func (v IsDurationValidator) Validate(ctx context.Context, req tfsdk.ValidateAttributeRequest, resp *tfsdk.ValidateAttributeResponse) {
+	var str types.String
+	diags := tfsdk.ValueAs(ctx, req.AttributeConfig, &str)
+	resp.Diagnostics.Append(diags...)
+	if diags.HasError() {
+		return
+	}
+
+	if str.IsUnknown() || str.IsNull() {
+		return
+	}
+
+	if d, err := time.ParseDuration(str.ValueString()); err == nil && d > 0 {
+		return
+	}
+
+	resp.Diagnostics.AddAttributeError(
+		req.AttributePath,
+		"Invalid value",
+		fmt.Sprintf("%s is not a valid duration, expected a positive value such as 30s, 10m or 1h", str.ValueString()),
+	)
+}
@@ -5,7 +5,6 @@ import (
 	"github.com/hashicorp/terraform-plugin-framework/attr"
 	"github.com/hashicorp/terraform-plugin-framework/diag"
 	"github.com/hashicorp/terraform-plugin-framework/tfsdk"
-	"github.com/hashicorp/terraform-plugin-log/tflog"
 	"github.com/hashicorp/terraform-plugin-log/tfsdklog"
 )
 
@@ -19,7 +18,7 @@ func RequiresReplaceIf(f RequiresReplaceIfFunc, description, markdownDescription
 
 // RequiresReplaceIfFunc is a conditional function used in the RequiresReplaceIf
 // plan modifier to determine whether the attribute requires replacement.
-type RequiresReplaceIfFunc func(ctx context.Context, state, config attr.Value, plan tfsdk.Plan) (bool, diag.Diagnostics)
+type RequiresReplaceIfFunc func(ctx context.Context, state, config attr.Value, plan tfsdk.Plan, priorState tfsdk.State) (bool, diag.Diagnostics)
 
 // RequiresReplaceIfModifier is an AttributePlanModifier that sets RequiresReplace
 // on the attribute if the conditional function returns true.
@@ -35,11 +34,6 @@ func (r RequiresReplaceIfModifier) Modify(ctx context.Context, req tfsdk.ModifyA
 		return
 	}
 
-	tflog.Info(ctx, "state "+req.State.Raw.String())
-	tflog.Info(ctx, "config "+req.Config.Raw.String())
-	tflog.Info(ctx, "plan "+req.Plan.Raw.String())
-	tflog.Info(ctx, "provider meta "+req.ProviderMeta.Raw.String())
-
 	if req.State.Raw.IsNull() {
 		// if we're creating the resource, no need to delete and
 		// recreate it
@@ -58,7 +52,7 @@ func (r RequiresReplaceIfModifier) Modify(ctx context.Context, req tfsdk.ModifyA
 		return
 	}
 
-	res, diags := r.f(ctx, req.AttributeState, req.AttributeConfig, req.Plan)
+	res, diags := r.f(ctx, req.AttributeState, req.AttributeConfig, req.Plan, req.State)
 	resp.Diagnostics.Append(diags...)
 
 	// If the function says to require replacing, we require replacing.
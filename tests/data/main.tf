@@ -8,8 +8,8 @@ terraform {
 }
 
 provider "multy" {
-  api_key  = "123"
-  location = "eu_west_1"
+  api_key          = "123"
+  default_location = "eu_west_1"
 }
 
 data multy_virtual_network vn {
@@ -0,0 +1,19 @@
+variable "cloud" {
+  type    = string
+  default = "aws"
+}
+
+resource multy_virtual_network vn {
+  name       = "test-account"
+  cidr_block = "10.0.0.0/16"
+  cloud      = var.cloud
+  location   = "us_east_1"
+  account    = "other"
+}
+
+resource multy_subnet subnet {
+  name               = "test-account"
+  cidr_block         = "10.0.1.0/24"
+  virtual_network_id = multy_virtual_network.vn.id
+  account            = "other"
+}